@@ -2,28 +2,47 @@ package app
 
 import (
 	"context"
+	"fmt"
 	"os"
-	"path/filepath"
 	"time"
 
 	"github.com/pkg/errors"
 	"openapi3-go-gen/pkg/generator"
-
-	spec3 "github.com/getkin/kin-openapi/openapi3"
 )
 
-func Run(input string, output string) error {
+type Options struct {
+	EmbedSpec       bool
+	MockServer      bool
+	AsyncInterfaces bool
+	Filter          generator.FilterOptions
+	Roots           []string
+	RefNames        map[string]string
+	NamingStrategy  generator.NamingStrategy
+	Initialisms     []string
+	PropertyOrder   generator.PropertyOrder
+	Router          string
+}
+
+func Run(inputs []string, output string, opts Options) error {
 	rootCtx, cancel := context.WithTimeout(context.Background(), time.Second*10)
 	defer cancel()
 
+	if err := generator.ValidateRouter(opts.Router); err != nil {
+		return err
+	}
+
 	if err := readTemplates(); err != nil {
 		return errors.WithStack(err)
 	}
 
-	l := spec3.NewLoader()
-	l.IsExternalRefsAllowed = true
+	naming := generator.NewNaming(opts.Initialisms)
 
-	doc, err := l.LoadFromFile(input)
+	loadOptions := generator.LoadOptions{
+		RefNames: opts.RefNames,
+		Naming:   naming,
+	}
+
+	doc, err := generator.LoadSpecWithOptions(loadOptions, inputs...)
 	if err != nil {
 		return errors.Wrapf(err, "failed while loading openapi spec")
 	}
@@ -32,15 +51,163 @@ func Run(input string, output string) error {
 		return errors.Wrapf(err, "failed while validating openapi spec")
 	}
 
-	flattener := generator.NewFlattener(doc)
+	operationFilter := generator.NewOperationFilter(doc, opts.Filter)
+	if err := operationFilter.Filter(); err != nil {
+		return errors.Wrapf(err, "failed while filtering operations")
+	}
+
+	pruned, err := generator.NewSchemaPruner(doc, opts.Roots).Prune()
+	if err != nil {
+		return errors.Wrapf(err, "failed while pruning schemas")
+	}
+
+	for _, component := range append(operationFilter.Skipped(), pruned...) {
+		fmt.Printf("Skipping: %s (%s)\n", component.Name, component.Reason)
+	}
+
+	modelNamer := generator.NewModelNamer(doc, opts.NamingStrategy, naming)
+	modelNamer.Helpers = generator.HelperOptions{
+		EmbedSpec:       opts.EmbedSpec,
+		MockServer:      opts.MockServer,
+		AsyncInterfaces: opts.AsyncInterfaces,
+		Router:          opts.Router,
+	}
+
+	if err := modelNamer.Resolve(); err != nil {
+		return errors.Wrapf(err, "failed while naming models")
+	}
+
+	flattener := generator.NewFlattener(doc, naming)
 
 	flatSchemaRefs := flattener.Flatten()
 
-	schemaResolver := generator.NewSchemaResolver(flatSchemaRefs)
+	schemaResolver := generator.NewSchemaResolver(doc, flatSchemaRefs, naming)
 
 	models := schemaResolver.Resolve()
 
-	gen := generator.NewGenerator()
+	problems := generator.NewProblemResolver(doc, models, naming).Resolve()
+
+	// every resolver runs before the first file is written, so a spec failing to resolve leaves the output as it was
+	contentResolver := generator.NewContentResolver(doc, models, naming)
+
+	contentOperations := contentResolver.Resolve()
+
+	for _, warning := range contentResolver.Warnings() {
+		fmt.Printf("Warning: %s\n", warning)
+	}
+
+	formResolver := generator.NewFormResolver(doc, models, naming)
+
+	forms, err := formResolver.Resolve()
+	if err != nil {
+		return errors.Wrapf(err, "failed while resolving form bodies")
+	}
+
+	for _, warning := range formResolver.Warnings() {
+		fmt.Printf("Warning: %s\n", warning)
+	}
+
+	streams := generator.NewStreamResolver(doc, models, naming).Resolve()
+
+	urlResolver := generator.NewURLResolver(doc, naming)
+
+	urls, err := urlResolver.Resolve()
+	if err != nil {
+		return errors.Wrapf(err, "failed while resolving URL params")
+	}
+
+	for _, warning := range urlResolver.Warnings() {
+		fmt.Printf("Warning: %s\n", warning)
+	}
+
+	serverOperations, err := generator.NewServerResolver(doc, naming).Resolve()
+	if err != nil {
+		return errors.Wrapf(err, "failed while resolving the server interface")
+	}
+
+	paginations, err := generator.NewPaginationResolver(doc, models, naming).Resolve()
+	if err != nil {
+		return errors.Wrapf(err, "failed while resolving pagination")
+	}
+
+	var mockOperations []*generator.MockOperation
+	if opts.MockServer {
+		mockOperations, err = generator.NewMockResolver(doc, naming).Resolve()
+		if err != nil {
+			return errors.Wrapf(err, "failed while resolving mock server examples")
+		}
+	}
+
+	var channels []*generator.AsyncChannelModel
+	if opts.AsyncInterfaces {
+		channels, err = generator.NewAsyncResolver(doc).Resolve()
+		if err != nil {
+			return errors.Wrapf(err, "failed while resolving asyncapi channels")
+		}
+	}
+
+	callbacks := generator.NewCallbackResolver(doc, models, naming).Resolve()
+
+	gen := generator.NewGenerator(naming)
+	gen.PropertyOrder = opts.PropertyOrder
+
+	if err := gen.GenerateProblemsToFile(problems, output); err != nil {
+		return err
+	}
+
+	if opts.EmbedSpec {
+		if err := gen.GenerateSpecToFile(doc, output); err != nil {
+			return err
+		}
+	}
+
+	if err := gen.GenerateAuthToFile(doc, output); err != nil {
+		return err
+	}
+
+	if err := gen.GenerateContentToFile(doc, contentOperations, output); err != nil {
+		return err
+	}
+
+	if err := gen.GenerateFormsToFile(forms, models, output); err != nil {
+		return err
+	}
+
+	if err := gen.GenerateStreamsToFile(streams, output); err != nil {
+		return err
+	}
+
+	if err := gen.GenerateURLsToFile(doc, urls, output); err != nil {
+		return err
+	}
+
+	if err := gen.GenerateServerToFile(serverOperations, opts.Router, output); err != nil {
+		return err
+	}
+
+	if err := gen.GenerateClientToFile(serverOperations, problems, output); err != nil {
+		return err
+	}
+
+	if err := gen.GeneratePaginationsToFile(paginations, output); err != nil {
+		return err
+	}
+
+	if opts.MockServer {
+		if err := gen.GenerateMockToFile(mockOperations, output); err != nil {
+			return err
+		}
+	}
+
+	if opts.AsyncInterfaces {
+		if err := gen.GenerateAsyncToFile(channels, output); err != nil {
+			return err
+		}
+	}
+
+	if err := gen.GenerateCallbacksToFile(doc, callbacks, output); err != nil {
+		return err
+	}
 
 	if err := gen.GenerateToFile(models, output); err != nil {
 		return err
@@ -50,14 +217,10 @@ func Run(input string, output string) error {
 }
 
 func readTemplates() error {
-	var structTmplPath string
-
 	templatesFolder := os.Getenv("CODEGEN_TEMPLATES_FOLDER")
 	if templatesFolder == "" {
-		structTmplPath = "pkg/generator/templates/struct.tmpl"
-	} else {
-		structTmplPath = filepath.Join(templatesFolder, "struct.tmpl")
+		templatesFolder = "pkg/generator/templates"
 	}
 
-	return generator.ReadTemplates(structTmplPath)
+	return generator.ReadTemplates(templatesFolder)
 }
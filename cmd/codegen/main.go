@@ -5,12 +5,30 @@ import (
 	"fmt"
 	"log"
 	"openapi3-go-gen/cmd/codegen/app"
+	"openapi3-go-gen/pkg/generator"
 	"os"
+	"strings"
 )
 
 func main() {
-	input := flag.String("input", "", "Path to openapi.yaml or openapi.json")
+	input := flag.String("input", "", "Path to openapi.yaml or openapi.json, or comma separated paths to JSON Schema documents")
 	output := flag.String("output", "", "Path to where generated files will be located")
+	router := flag.String("router", "", "Generate registration functions of the server interface for a router: chi, echo or gin")
+	embedSpec := flag.Bool("embed-spec", false, "Embed the bundled spec and generate a request validation middleware")
+	mockServer := flag.Bool("mock-server", false, "Generate a MockServer responding with the spec's examples")
+	asyncInterfaces := flag.Bool("async-interfaces", false, "Generate publisher and subscriber interfaces for AsyncAPI channels")
+	includeTags := flag.String("include-tags", "", "Comma separated tags of operations to generate")
+	excludeTags := flag.String("exclude-tags", "", "Comma separated tags of operations to skip")
+	includePaths := flag.String("include-paths", "", "Comma separated path globs of operations to generate")
+	excludePaths := flag.String("exclude-paths", "", "Comma separated path globs of operations to skip")
+	includeOperationIDs := flag.String("include-operation-ids", "", "Comma separated operationIds to generate")
+	excludeOperationIDs := flag.String("exclude-operation-ids", "", "Comma separated operationIds to skip")
+	excludeInternal := flag.Bool("exclude-internal", false, "Skip operations marked with x-internal")
+	refNames := flag.String("ref-names", "", "Comma separated file[#pointer]=Name pairs naming the models of externally referenced schemas")
+	initialisms := flag.String("initialisms", "", "Comma separated initialisms to keep upper case in identifiers besides the common ones like ID, URL and HTTP")
+	namingStrategy := flag.String("naming-strategy", "fail", "How to resolve model name collisions: fail, suffix or path")
+	propertyOrder := flag.String("property-order", "declared", "Order of struct fields: declared, following the spec and x-order, or alphabetical")
+	roots := flag.String("roots", "", "Comma separated names or regexes of schemas to generate together with the schemas they reference")
 	flag.Parse()
 
 	if *input == "" {
@@ -21,17 +39,62 @@ func main() {
 		log.Fatalln("'output' flag must be provided")
 	}
 
-	if _, err := os.Stat(*input); os.IsNotExist(err) {
-		log.Fatalf("File %s does not exist\n", *input)
+	inputs := splitList(*input)
+	for _, path := range inputs {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			log.Fatalf("File %s does not exist\n", path)
+		}
 	}
 
 	if _, err := os.Stat(*output); os.IsNotExist(err) {
 		log.Fatalf("Directory %s does not exist\n", *output)
 	}
 
-	err := app.Run(*input, *output)
+	names := make(map[string]string)
+	for _, pair := range splitList(*refNames) {
+		ref, name, ok := strings.Cut(pair, "=")
+		if !ok || ref == "" || name == "" {
+			log.Fatalf("Ref name %s must look like file[#pointer]=Name\n", pair)
+		}
+
+		names[ref] = name
+	}
+
+	err := app.Run(inputs, *output, app.Options{
+		EmbedSpec:       *embedSpec,
+		MockServer:      *mockServer,
+		AsyncInterfaces: *asyncInterfaces,
+		Filter: generator.FilterOptions{
+			IncludeTags:         splitList(*includeTags),
+			ExcludeTags:         splitList(*excludeTags),
+			IncludePaths:        splitList(*includePaths),
+			ExcludePaths:        splitList(*excludePaths),
+			IncludeOperationIDs: splitList(*includeOperationIDs),
+			ExcludeOperationIDs: splitList(*excludeOperationIDs),
+			ExcludeInternal:     *excludeInternal,
+		},
+		Roots:          splitList(*roots),
+		RefNames:       names,
+		NamingStrategy: generator.NamingStrategy(*namingStrategy),
+		Initialisms:    splitList(*initialisms),
+		PropertyOrder:  generator.PropertyOrder(*propertyOrder),
+		Router:         *router,
+	})
 	if err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
 }
+
+func splitList(value string) []string {
+	if value == "" {
+		return nil
+	}
+
+	items := strings.Split(value, ",")
+	for i, item := range items {
+		items[i] = strings.TrimSpace(item)
+	}
+
+	return items
+}
@@ -1,3 +1,5 @@
+// Code generated by openapi3-go-gen. DO NOT EDIT.
+
 package openapi
 
 import (
@@ -6,14 +8,21 @@ import (
 )
 
 type Animal struct {
-	Meow     string
-	Unknown  interface{}
-	Unknowns []interface{}
-	Bark     string
-	Age      int
+	// Constraints: minLength 3, maxLength 255, pattern ^\d{3}-\d{2}-\d{4}$
+	Meow    string      `json:"meow"`
+	Unknown interface{} `json:"unknown,omitempty"`
+	// Constraints: minItems 5, maxItems 100
+	Unknowns []interface{} `json:"unknowns"`
+	// Constraints: one of rark, bark, kararak, howk
+	Bark string `json:"bark,omitempty"`
+	// Constraints: exclusive minimum 3, maximum 20
+	Age int `json:"age,omitempty"`
 }
 
 func (instance *Animal) Validate() error {
+	if instance.Meow == "" {
+		return errors.New("Value for field Meow must be not empty")
+	}
 	if len(instance.Meow) > 255 {
 		return errors.New("Field Meow size should not be greater than 255")
 	}
@@ -33,8 +42,8 @@ func (instance *Animal) Validate() error {
 		return errors.New("Number of elements of Unknowns should not be less than 5")
 	}
 	containsBark := false
-	enum := []string{"rark", "bark", "kararak", "howk"}
-	for _, v := range enum {
+	enumBark := []string{"rark", "bark", "kararak", "howk"}
+	for _, v := range enumBark {
 		if v == instance.Bark {
 			containsBark = true
 			break
@@ -1,11 +1,13 @@
+// Code generated by openapi3-go-gen. DO NOT EDIT.
+
 package openapi
 
 type CreateUser struct {
-	Merchant Merchant
-	Photos   string
-	Profile  UserProfile
-	Company  Company
-	Id       string
+	ID       string      `json:"id,omitempty"`
+	Profile  UserProfile `json:"profile,omitempty"`
+	Company  Company     `json:"company,omitempty"`
+	Merchant Merchant    `json:"merchant,omitempty"`
+	Photos   []string    `json:"photos,omitempty"`
 }
 
 func (instance *CreateUser) Validate() error {
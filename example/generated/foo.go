@@ -1,10 +1,12 @@
+// Code generated by openapi3-go-gen. DO NOT EDIT.
+
 package openapi
 
 type Foo struct {
-	Bar    string
-	Baz    Baz
-	King   FooKing
-	Queens []FooQueen
+	Bar    string     `json:"bar,omitempty"`
+	Baz    Baz        `json:"baz,omitempty"`
+	King   FooKing    `json:"king,omitempty"`
+	Queens []FooQueen `json:"queens,omitempty"`
 }
 
 func (instance *Foo) Validate() error {
@@ -23,7 +23,7 @@ func main() {
 		os.Exit(1)
 	}
 
-	err := app.Run(src, dest)
+	err := app.Run([]string{src}, dest, app.Options{})
 	if err != nil {
 		fmt.Println(err)
 		os.Exit(1)
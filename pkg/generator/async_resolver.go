@@ -0,0 +1,50 @@
+package generator
+
+import (
+	"github.com/pkg/errors"
+
+	spec3 "github.com/getkin/kin-openapi/openapi3"
+)
+
+const AsyncAPIChannelsExtension = "x-asyncapi-channels"
+
+type AsyncMessageModel struct {
+	Name        string `json:"name"`
+	PayloadType string `json:"payloadType"`
+	HeadersType string `json:"headersType,omitempty"`
+}
+
+type AsyncChannelModel struct {
+	Name     string               `json:"name"`
+	Address  string               `json:"address"`
+	Messages []*AsyncMessageModel `json:"messages"`
+}
+
+// AsyncResolver reads the channels converted by the loader, their names are given by the Naming of the LoadOptions.
+type AsyncResolver struct {
+	doc *spec3.T
+}
+
+func NewAsyncResolver(doc *spec3.T) *AsyncResolver {
+	return &AsyncResolver{
+		doc: doc,
+	}
+}
+
+func (r *AsyncResolver) Resolve() ([]*AsyncChannelModel, error) {
+	channels := make([]*AsyncChannelModel, 0)
+
+	if _, err := decodeExtension(r.doc.ExtensionProps, AsyncAPIChannelsExtension, &channels); err != nil {
+		return nil, errors.Wrapf(err, "invalid %s", AsyncAPIChannelsExtension)
+	}
+
+	result := make([]*AsyncChannelModel, 0, len(channels))
+
+	for _, channel := range channels {
+		if len(channel.Messages) > 0 {
+			result = append(result, channel)
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,275 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	spec3 "github.com/getkin/kin-openapi/openapi3"
+)
+
+const componentMessagesPrefix = "#/components/messages/"
+
+func isAsyncAPI(doc map[string]interface{}) bool {
+	_, ok := doc["asyncapi"]
+
+	return ok
+}
+
+// asyncAPIConverter turns an AsyncAPI 2.x or 3.0 document into an OpenAPI document whose component schemas
+// hold the message payloads and headers, keeping the channels in the x-asyncapi-channels extension.
+type asyncAPIConverter struct {
+	doc         map[string]interface{}
+	normalizer  *openAPI31Normalizer
+	messages    map[string]*AsyncMessageModel
+	initialisms initialisms
+}
+
+func asyncAPIToOpenAPI(doc map[string]interface{}, extra initialisms) (map[string]interface{}, error) {
+	c := &asyncAPIConverter{
+		doc: doc,
+		normalizer: &openAPI31Normalizer{
+			schemas:     make(map[string]interface{}),
+			hoisted:     make(map[string]interface{}),
+			renamed:     make(map[string]string),
+			initialisms: extra,
+		},
+		messages:    make(map[string]*AsyncMessageModel),
+		initialisms: extra,
+	}
+
+	return c.convert()
+}
+
+func (c *asyncAPIConverter) convert() (map[string]interface{}, error) {
+	components, _ := c.doc["components"].(map[string]interface{})
+
+	schemas, _ := components["schemas"].(map[string]interface{})
+	for _, name := range sortedKeys(schemas) {
+		c.normalizer.schemas[name] = c.normalizer.normalizeSchema(schemas[name], componentSchemasPrefix+escapePointerToken(name))
+	}
+
+	componentMessages, _ := components["messages"].(map[string]interface{})
+	for _, key := range sortedKeys(componentMessages) {
+		message, err := c.convertMessage(key, componentMessages[key], componentMessagesPrefix+escapePointerToken(key))
+		if err != nil {
+			return nil, err
+		}
+
+		c.messages[componentMessagesPrefix+escapePointerToken(key)] = message
+	}
+
+	channels, err := c.convertChannels()
+	if err != nil {
+		return nil, err
+	}
+
+	c.normalizer.flushHoisted()
+	c.normalizer.rewriteRefs(c.normalizer.schemas)
+
+	info, _ := c.doc["info"].(map[string]interface{})
+
+	return map[string]interface{}{
+		"openapi":                 "3.0.3",
+		"info":                    info,
+		"paths":                   map[string]interface{}{},
+		AsyncAPIChannelsExtension: channels,
+		"components": map[string]interface{}{
+			"schemas": c.normalizer.schemas,
+		},
+	}, nil
+}
+
+func (c *asyncAPIConverter) convertChannels() ([]*AsyncChannelModel, error) {
+	channels, _ := c.doc["channels"].(map[string]interface{})
+
+	models := make([]*AsyncChannelModel, 0, len(channels))
+
+	for _, key := range sortedKeys(channels) {
+		channel, _ := channels[key].(map[string]interface{})
+		pointer := "#/channels/" + escapePointerToken(key)
+
+		model := &AsyncChannelModel{
+			Name:    goIdentifier(key, c.initialisms),
+			Address: key,
+		}
+
+		if address, ok := channel["address"].(string); ok {
+			model.Address = address
+		}
+
+		// 3.0 lists the messages of the channel, 2.x nests them into the publish and subscribe operations
+		channelMessages, _ := channel["messages"].(map[string]interface{})
+		for _, name := range sortedKeys(channelMessages) {
+			message, err := c.resolveMessage(name, channelMessages[name], pointer+"/messages/"+escapePointerToken(name))
+			if err != nil {
+				return nil, err
+			}
+
+			model.Messages = appendMessage(model.Messages, message)
+		}
+
+		for _, operationName := range []string{"publish", "subscribe"} {
+			operation, ok := channel[operationName].(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			operationPointer := pointer + "/" + operationName + "/message"
+
+			nodes := []interface{}{operation["message"]}
+			if message, ok := operation["message"].(map[string]interface{}); ok {
+				if oneOf, ok := message["oneOf"].([]interface{}); ok {
+					nodes = oneOf
+				}
+			}
+
+			for i, node := range nodes {
+				fallbackName := model.Name + goIdentifier(operationName, c.initialisms)
+				if len(nodes) > 1 {
+					fallbackName += fmt.Sprint(i + 1)
+				}
+
+				message, err := c.resolveMessage(fallbackName, node, fmt.Sprintf("%s/%d", operationPointer, i))
+				if err != nil {
+					return nil, err
+				}
+
+				model.Messages = appendMessage(model.Messages, message)
+			}
+		}
+
+		models = append(models, model)
+	}
+
+	return models, nil
+}
+
+func (c *asyncAPIConverter) resolveMessage(fallbackName string, node interface{}, pointer string) (*AsyncMessageModel, error) {
+	message, ok := node.(map[string]interface{})
+	if !ok {
+		return nil, errors.Errorf("message %s is not an object", pointer)
+	}
+
+	if ref, ok := message["$ref"].(string); ok {
+		resolved, ok := c.messages[ref]
+		if !ok {
+			return nil, errors.Errorf("message %s is not defined", ref)
+		}
+
+		return resolved, nil
+	}
+
+	return c.convertMessage(fallbackName, message, pointer)
+}
+
+func (c *asyncAPIConverter) convertMessage(key string, node interface{}, pointer string) (*AsyncMessageModel, error) {
+	message, ok := node.(map[string]interface{})
+	if !ok {
+		return nil, errors.Errorf("message %s is not an object", pointer)
+	}
+
+	name := key
+	for _, field := range []string{"messageId", "name"} {
+		if value, ok := message[field].(string); ok && value != "" {
+			name = value
+		}
+	}
+
+	model := &AsyncMessageModel{
+		Name: goIdentifier(name, c.initialisms),
+	}
+
+	if format, ok := message["schemaFormat"].(string); ok && !isJSONSchemaFormat(format) {
+		return nil, errors.Errorf("schema format %s of message %s is not supported", format, name)
+	}
+
+	payload := message["payload"]
+
+	// 3.0 wraps non-default payload formats into a multi format schema object
+	if multiFormat, ok := payload.(map[string]interface{}); ok {
+		if schema, ok := multiFormat["schema"]; ok {
+			if format, ok := multiFormat["schemaFormat"].(string); ok && !isJSONSchemaFormat(format) {
+				return nil, errors.Errorf("schema format %s of message %s is not supported", format, name)
+			}
+
+			payload = schema
+		}
+	}
+
+	if payload != nil {
+		model.PayloadType = c.collectSchema(model.Name, payload, pointer+"/payload")
+	}
+
+	if headers, ok := message["headers"]; ok {
+		model.HeadersType = c.collectSchema(model.Name+"Headers", headers, pointer+"/headers")
+	}
+
+	return model, nil
+}
+
+// collectSchema registers the payload or headers schema of a message as a component and returns the Go type of
+// the message's argument: a pointer to the model of an object, the Go type of a scalar or a slice of the items.
+func (c *asyncAPIConverter) collectSchema(name string, node interface{}, pointer string) string {
+	if schema, ok := node.(map[string]interface{}); ok {
+		if ref, ok := schema["$ref"].(string); ok && len(schema) == 1 && strings.HasPrefix(ref, componentSchemasPrefix) {
+			return "*" + refToModelName(ref, c.initialisms)
+		}
+	}
+
+	normalized := c.normalizer.normalizeSchema(node, componentSchemasPrefix+name)
+
+	if schema, ok := normalized.(map[string]interface{}); ok {
+		tp, _ := schema["type"].(string)
+
+		switch {
+		case isScalar(tp):
+			return rawScalarGoType(schema)
+		case isArray(tp):
+			items, ok := schema["items"]
+			if !ok {
+				return "[]interface{}"
+			}
+
+			return "[]" + strings.TrimPrefix(c.collectSchema(name+"Item", items, pointer+"/items"), "*")
+		}
+	}
+
+	if _, taken := c.normalizer.schemas[name]; taken {
+		name += "Payload"
+	}
+
+	c.normalizer.renamed[pointer] = componentSchemasPrefix + name
+	c.normalizer.schemas[name] = normalized
+
+	return "*" + propToModelName(name, c.initialisms)
+}
+
+func rawScalarGoType(schema map[string]interface{}) string {
+	tp, _ := schema["type"].(string)
+	format, _ := schema["format"].(string)
+	nullable, _ := schema["nullable"].(bool)
+
+	if tp == "string" && format == "binary" {
+		return "[]byte"
+	}
+
+	return mapScalarType2GoType(&spec3.Schema{Type: tp, Format: format, Nullable: nullable}, false).Name
+}
+
+func appendMessage(messages []*AsyncMessageModel, message *AsyncMessageModel) []*AsyncMessageModel {
+	for _, existing := range messages {
+		if existing == message || existing.Name == message.Name {
+			return messages
+		}
+	}
+
+	return append(messages, message)
+}
+
+func isJSONSchemaFormat(format string) bool {
+	return strings.HasPrefix(format, "application/vnd.aai.asyncapi") ||
+		strings.HasPrefix(format, "application/schema+json") ||
+		strings.HasPrefix(format, "application/schema+yaml")
+}
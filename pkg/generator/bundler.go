@@ -0,0 +1,174 @@
+package generator
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	spec3 "github.com/getkin/kin-openapi/openapi3"
+)
+
+const (
+	componentSchemasPrefix = "#/components/schemas/"
+	goExtensionPrefix      = "x-go-"
+)
+
+type strippedExtension struct {
+	extensions map[string]interface{}
+	name       string
+	value      interface{}
+}
+
+type Bundler struct {
+	doc *spec3.T
+}
+
+func NewBundler(doc *spec3.T) *Bundler {
+	return &Bundler{doc: doc}
+}
+
+// Bundle marshals the document into a single self-contained JSON,
+// inlining every ref which points outside of the document itself.
+// The x-go-* extensions only steer the generator and are left out.
+func (b *Bundler) Bundle() ([]byte, error) {
+	stripped := make([]strippedExtension, 0)
+
+	visitExtensions(reflect.ValueOf(b.doc), make(map[uintptr]bool), func(extensions map[string]interface{}) {
+		for name, value := range extensions {
+			if strings.HasPrefix(name, goExtensionPrefix) {
+				stripped = append(stripped, strippedExtension{extensions: extensions, name: name, value: value})
+				delete(extensions, name)
+			}
+		}
+	})
+
+	defer func() {
+		for _, extension := range stripped {
+			extension.extensions[extension.name] = extension.value
+		}
+	}()
+
+	inlined := make(map[*string]string)
+
+	visitRefs(reflect.ValueOf(b.doc), make(map[uintptr]bool), func(ref *string, value interface{}) {
+		if *ref == "" || b.isLocalRef(*ref, value) {
+			return
+		}
+
+		inlined[ref] = *ref
+		*ref = ""
+	})
+
+	defer func() {
+		for ref, original := range inlined {
+			*ref = original
+		}
+	}()
+
+	return json.MarshalIndent(b.doc, "", "  ")
+}
+
+func (b *Bundler) isLocalRef(ref string, value interface{}) bool {
+	if !strings.HasPrefix(ref, "#/") {
+		return false
+	}
+
+	if !strings.HasPrefix(ref, componentSchemasPrefix) {
+		return true
+	}
+
+	// refs from external files look local as well, but point to the components of those files
+	component, ok := b.doc.Components.Schemas[strings.TrimPrefix(ref, componentSchemasPrefix)]
+
+	return ok && value == interface{}(component.Value)
+}
+
+// visitRefs walks through every XxxRef struct (SchemaRef, ParameterRef, ...) reachable from v
+// and calls visit with a pointer to its Ref field and its Value.
+func visitRefs(v reflect.Value, visited map[uintptr]bool, visit func(ref *string, value interface{})) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() || visited[v.Pointer()] {
+			return
+		}
+
+		visited[v.Pointer()] = true
+
+		visitRefs(v.Elem(), visited, visit)
+	case reflect.Interface:
+		if !v.IsNil() {
+			visitRefs(v.Elem(), visited, visit)
+		}
+	case reflect.Struct:
+		ref := v.FieldByName("Ref")
+		value := v.FieldByName("Value")
+
+		if ref.IsValid() && ref.Kind() == reflect.String && ref.CanAddr() && value.IsValid() {
+			visit(ref.Addr().Interface().(*string), value.Interface())
+		}
+
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				visitRefs(v.Field(i), visited, visit)
+			}
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			visitRefs(iter.Value(), visited, visit)
+		}
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return
+		}
+
+		for i := 0; i < v.Len(); i++ {
+			visitRefs(v.Index(i), visited, visit)
+		}
+	}
+}
+
+// visitExtensions walks through every ExtensionProps reachable from v and calls visit with its extensions.
+func visitExtensions(v reflect.Value, visited map[uintptr]bool, visit func(extensions map[string]interface{})) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() || visited[v.Pointer()] {
+			return
+		}
+
+		visited[v.Pointer()] = true
+
+		visitExtensions(v.Elem(), visited, visit)
+	case reflect.Interface:
+		if !v.IsNil() {
+			visitExtensions(v.Elem(), visited, visit)
+		}
+	case reflect.Struct:
+		if props, ok := v.Interface().(spec3.ExtensionProps); ok {
+			if props.Extensions != nil {
+				visit(props.Extensions)
+			}
+
+			return
+		}
+
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				visitExtensions(v.Field(i), visited, visit)
+			}
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			visitExtensions(iter.Value(), visited, visit)
+		}
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return
+		}
+
+		for i := 0; i < v.Len(); i++ {
+			visitExtensions(v.Index(i), visited, visit)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package generator
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	spec3 "github.com/getkin/kin-openapi/openapi3"
+)
+
+type CallbackModel struct {
+	Name        string
+	Expression  string
+	Method      string
+	BodyType    string
+	HasValidate bool
+	StatusCode  int
+	IsWebhook   bool
+}
+
+type CallbackResolver struct {
+	doc    *spec3.T
+	naming Naming
+	models map[string]*Model
+}
+
+func NewCallbackResolver(doc *spec3.T, models map[string]*Model, naming Naming) *CallbackResolver {
+	return &CallbackResolver{
+		doc:    doc,
+		models: models,
+		naming: naming,
+	}
+}
+
+func (r *CallbackResolver) Resolve() []*CallbackModel {
+	callbacks := make([]*CallbackModel, 0)
+	extra := r.naming.initialisms
+
+	for _, callback := range listCallbacks(r.doc, extra) {
+		model := &CallbackModel{
+			Name:       callback.Name,
+			Expression: callback.Expression,
+			Method:     strings.ToUpper(callback.Method),
+			StatusCode: successStatusCode(callback.Operation),
+			IsWebhook:  callback.IsWebhook,
+		}
+
+		if mediaType := getJSONMediaType(callback.Operation); mediaType != nil {
+			if custom := getCustomTypeSchemaRef(mediaType.Schema); custom != nil {
+				modelName := customSchemaModelName(callback.Name, "body", custom, extra)
+
+				if isArray(mediaType.Schema.Value.Type) {
+					model.BodyType = "[]*" + modelName
+				} else {
+					model.BodyType = "*" + modelName
+					_, model.HasValidate = r.models[modelName]
+				}
+			} else {
+				model.BodyType = mapSimpleSchema2GoType(mediaType.Schema.Value).Name
+			}
+		}
+
+		callbacks = append(callbacks, model)
+	}
+
+	return callbacks
+}
+
+func successStatusCode(op *spec3.Operation) int {
+	codes := make([]string, 0, len(op.Responses))
+	for code := range op.Responses {
+		codes = append(codes, code)
+	}
+
+	sort.Strings(codes)
+
+	for _, code := range codes {
+		if statusCode, err := strconv.Atoi(code); err == nil && statusCode >= 200 && statusCode < 300 {
+			return statusCode
+		}
+	}
+
+	return http.StatusOK
+}
@@ -0,0 +1,238 @@
+package generator
+
+import (
+	"fmt"
+	"mime"
+	"sort"
+	"strings"
+
+	spec3 "github.com/getkin/kin-openapi/openapi3"
+)
+
+// ContentBody is a request or response body of an operation encoded by content type. ContentTypes lists the media
+// types the codecs of content.go support for GoType, the one to fall back on first. Media types of another Go type
+// get a body of their own named by Suffix; a binary body is read and written as []byte and as an io.Reader.
+type ContentBody struct {
+	GoType       string
+	Suffix       string
+	IsBinary     bool
+	ContentTypes []string
+}
+
+// ContentOperation picks the codec of its request body and its success response by content type. Form bodies and
+// streams have helpers of their own, so they are left out.
+type ContentOperation struct {
+	Name       string
+	StatusCode int
+	Requests   []*ContentBody
+	Responses  []*ContentBody
+}
+
+type ContentResolver struct {
+	doc      *spec3.T
+	naming   Naming
+	models   map[string]*Model
+	warnings []string
+}
+
+func NewContentResolver(doc *spec3.T, models map[string]*Model, naming Naming) *ContentResolver {
+	return &ContentResolver{
+		doc:    doc,
+		models: models,
+		naming: naming,
+	}
+}
+
+func (r *ContentResolver) Resolve() []*ContentOperation {
+	known := func(name string) bool {
+		_, ok := r.models[name]
+		return ok
+	}
+
+	operations := make([]*ContentOperation, 0)
+	extra := r.naming.initialisms
+	r.warnings = nil
+
+	for _, op := range listOperations(r.doc, extra) {
+		operation := &ContentOperation{
+			Name:       op.Name,
+			StatusCode: successStatusCode(op.Operation),
+		}
+
+		var skipped []string
+		operation.Requests, operation.Responses, skipped = operationContentBodies(op, known, extra)
+
+		for _, skip := range skipped {
+			r.warnings = append(r.warnings, fmt.Sprintf("operation %s has no codec for %s", op.Name, skip))
+		}
+
+		if len(operation.Requests) == 0 && len(operation.Responses) == 0 {
+			continue
+		}
+
+		operations = append(operations, operation)
+	}
+
+	return operations
+}
+
+// Warnings lists the body media types of the last Resolve that got no codec, as their schema has no model or their
+// Go type can't be written as text.
+func (r *ContentResolver) Warnings() []string {
+	return r.warnings
+}
+
+// operationContentBodies resolves the request bodies and the success responses of op, known tells the names of the
+// models generated for the spec. The media types left without a codec are listed last.
+func operationContentBodies(op operation, known func(string) bool, extra initialisms) ([]*ContentBody, []*ContentBody, []string) {
+	var requests, responses []*ContentBody
+	var skipped []string
+
+	if op.RequestBody != nil && op.RequestBody.Value != nil {
+		var skippedTypes []string
+		requests, skippedTypes = contentBodies(op.RequestBody.Value.Content, known, extra)
+
+		for _, contentType := range skippedTypes {
+			skipped = append(skipped, "the request body "+contentType)
+		}
+	}
+
+	responseRef := op.Responses.Get(successStatusCode(op.Operation))
+	if responseRef != nil && responseRef.Value != nil {
+		var skippedTypes []string
+		responses, skippedTypes = contentBodies(responseRef.Value.Content, known, extra)
+
+		for _, contentType := range skippedTypes {
+			skipped = append(skipped, "the response "+contentType)
+		}
+	}
+
+	return requests, responses, skipped
+}
+
+// contentBodies groups the media types of content by Go type, the group of application/json comes first and has no
+// suffix. Binary media types are a group of their own whatever their schema.
+func contentBodies(content spec3.Content, known func(string) bool, extra initialisms) ([]*ContentBody, []string) {
+	contentTypes := make([]string, 0, len(content))
+	for contentType := range content {
+		contentTypes = append(contentTypes, contentType)
+	}
+
+	sort.SliceStable(contentTypes, func(i, j int) bool {
+		if isJSON := contentTypes[i] == "application/json"; isJSON != (contentTypes[j] == "application/json") {
+			return isJSON
+		}
+
+		return contentTypes[i] < contentTypes[j]
+	})
+
+	var bodies []*ContentBody
+	var skipped []string
+
+	groups := make(map[string]*ContentBody)
+
+	for _, contentType := range contentTypes {
+		kind := contentKind(contentType)
+		if kind == "" {
+			continue
+		}
+
+		goType, group := contentGoType(content[contentType].Schema, known, extra), ""
+
+		switch {
+		case kind == "binary":
+			goType, group = "[]byte", "binary"
+		case goType == "", kind == "text" && goType != "string" && goType != "*string":
+			skipped = append(skipped, contentType)
+			continue
+		default:
+			group = goType
+		}
+
+		body, ok := groups[group]
+		if !ok {
+			body = &ContentBody{GoType: goType, IsBinary: group == "binary"}
+			groups[group] = body
+			bodies = append(bodies, body)
+		}
+
+		body.ContentTypes = append(body.ContentTypes, contentType)
+	}
+
+	suffixes := make(map[string]bool)
+
+	for i, body := range bodies {
+		if i == 0 {
+			continue
+		}
+
+		mediaType, _, _ := mime.ParseMediaType(body.ContentTypes[0])
+
+		body.Suffix = goIdentifier(mediaType[strings.Index(mediaType, "/")+1:], extra)
+		if suffixes[body.Suffix] {
+			body.Suffix = goIdentifier(mediaType, extra)
+		}
+
+		suffixes[body.Suffix] = true
+	}
+
+	return bodies, skipped
+}
+
+// contentKind mirrors the contentKind of content.go, leaving out the media types of problems, forms and streams.
+func contentKind(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+
+	switch {
+	case mediaType == ProblemMediaType || mediaType == EventStreamMediaType || mediaType == NDJSONMediaType:
+		return ""
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		return "json"
+	case mediaType == "application/xml" || mediaType == "text/xml" || strings.HasSuffix(mediaType, "+xml"):
+		return "xml"
+	case strings.HasPrefix(mediaType, "text/"):
+		return "text"
+	case mediaType == "application/octet-stream":
+		return "binary"
+	}
+
+	return ""
+}
+
+// contentGoType names the Go type of a body schema, inline objects have no model and get no codec.
+func contentGoType(schemaRef *spec3.SchemaRef, known func(string) bool, extra initialisms) string {
+	if schemaRef == nil || schemaRef.Value == nil {
+		return ""
+	}
+
+	if isBinary(schemaRef.Value) {
+		return "[]byte"
+	}
+
+	if custom := getCustomTypeSchemaRef(schemaRef); custom != nil {
+		if custom.Ref == "" && schemaGoName(custom.Value) == "" {
+			return ""
+		}
+
+		name := customSchemaModelName("", "", custom, extra)
+		if !known(name) {
+			return ""
+		}
+
+		if isArray(schemaRef.Value.Type) {
+			return "[]" + name
+		}
+
+		return name
+	}
+
+	goType := mapSimpleSchema2GoType(schemaRef.Value)
+	if goType == nil || strings.Contains(goType.Name, "interface{}") || strings.Contains(goType.Name, FileTypeName) {
+		return ""
+	}
+
+	return goType.Name
+}
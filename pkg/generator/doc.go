@@ -0,0 +1,157 @@
+package generator
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	spec3 "github.com/getkin/kin-openapi/openapi3"
+)
+
+const docCommentWidth = 80
+
+// modelDoc lists the lines of the doc comment of a model, an empty line separates paragraphs. A title repeating
+// the name of the model is left out.
+func modelDoc(name string, schema *spec3.Schema, extra initialisms) []string {
+	if schema == nil {
+		return nil
+	}
+
+	title := schema.Title
+	if goIdentifier(title, extra) == name {
+		title = ""
+	}
+
+	return docLines(title, schema, nil, "Deprecated: the schema is marked as deprecated in the spec.")
+}
+
+// propDoc lists the lines of the doc comment of a field. Props referring to a component are left to the doc of
+// the component's model.
+func propDoc(schemaRef *spec3.SchemaRef) []string {
+	if schemaRef == nil || schemaRef.Ref != "" || schemaRef.Value == nil {
+		return nil
+	}
+
+	deprecated := "Deprecated: the property is marked as deprecated in the spec."
+
+	return docLines(schemaRef.Value.Title, schemaRef.Value, constraints(schemaRef.Value), deprecated)
+}
+
+func docLines(title string, schema *spec3.Schema, constraints []string, deprecated string) []string {
+	paragraphs := make([]string, 0)
+
+	if title != "" {
+		paragraphs = append(paragraphs, title)
+	}
+
+	if schema.Description != "" {
+		paragraphs = append(paragraphs, strings.Split(strings.ReplaceAll(schema.Description, "\r\n", "\n"), "\n\n")...)
+	}
+
+	if schema.Example != nil {
+		if example, err := json.Marshal(schema.Example); err == nil {
+			paragraphs = append(paragraphs, "Example: "+string(example))
+		}
+	}
+
+	if len(constraints) > 0 {
+		paragraphs = append(paragraphs, "Constraints: "+strings.Join(constraints, ", "))
+	}
+
+	if schema.Deprecated {
+		paragraphs = append(paragraphs, deprecated)
+	}
+
+	lines := make([]string, 0)
+
+	for _, paragraph := range paragraphs {
+		wrapped := wrapWords(strings.Fields(paragraph), docCommentWidth)
+		if len(wrapped) == 0 {
+			continue
+		}
+
+		if len(lines) > 0 {
+			lines = append(lines, "")
+		}
+
+		lines = append(lines, wrapped...)
+	}
+
+	return lines
+}
+
+func wrapWords(words []string, width int) []string {
+	lines := make([]string, 0)
+	line := ""
+
+	for _, word := range words {
+		if line != "" && len(line)+1+len(word) > width {
+			lines = append(lines, line)
+			line = ""
+		}
+
+		if line != "" {
+			line += " "
+		}
+
+		line += word
+	}
+
+	if line != "" {
+		lines = append(lines, line)
+	}
+
+	return lines
+}
+
+func constraints(schema *spec3.Schema) []string {
+	summary := make([]string, 0)
+
+	if len(schema.Enum) > 0 {
+		values := make([]string, 0, len(schema.Enum))
+		for _, value := range schema.Enum {
+			values = append(values, fmt.Sprint(value))
+		}
+
+		summary = append(summary, "one of "+strings.Join(values, ", "))
+	}
+
+	if schema.MinLength > 0 {
+		summary = append(summary, fmt.Sprintf("minLength %d", schema.MinLength))
+	}
+
+	if schema.MaxLength != nil {
+		summary = append(summary, fmt.Sprintf("maxLength %d", *schema.MaxLength))
+	}
+
+	if schema.Min != nil {
+		summary = append(summary, boundConstraint("minimum", *schema.Min, schema.ExclusiveMin))
+	}
+
+	if schema.Max != nil {
+		summary = append(summary, boundConstraint("maximum", *schema.Max, schema.ExclusiveMax))
+	}
+
+	if schema.Pattern != "" {
+		summary = append(summary, "pattern "+schema.Pattern)
+	}
+
+	if schema.MinItems > 0 {
+		summary = append(summary, fmt.Sprintf("minItems %d", schema.MinItems))
+	}
+
+	if schema.MaxItems != nil {
+		summary = append(summary, fmt.Sprintf("maxItems %d", *schema.MaxItems))
+	}
+
+	return summary
+}
+
+func boundConstraint(name string, value float64, exclusive bool) string {
+	if exclusive {
+		name = "exclusive " + name
+	}
+
+	return name + " " + strconv.FormatFloat(value, 'f', -1, 64)
+}
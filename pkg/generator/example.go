@@ -0,0 +1,116 @@
+package generator
+
+import (
+	"sort"
+
+	spec3 "github.com/getkin/kin-openapi/openapi3"
+)
+
+const maxExampleDepth = 8
+
+func schemaExample(schemaRef *spec3.SchemaRef) interface{} {
+	return synthesizeExample(schemaRef, 0)
+}
+
+func synthesizeExample(schemaRef *spec3.SchemaRef, depth int) interface{} {
+	if schemaRef == nil || schemaRef.Value == nil || depth > maxExampleDepth {
+		return nil
+	}
+
+	schema := schemaRef.Value
+
+	switch {
+	case schema.Example != nil:
+		return schema.Example
+	case schema.Default != nil:
+		return schema.Default
+	case len(schema.Enum) > 0:
+		return schema.Enum[0]
+	case len(schema.OneOf) > 0:
+		return synthesizeExample(schema.OneOf[0], depth+1)
+	case len(schema.AnyOf) > 0:
+		return synthesizeExample(schema.AnyOf[0], depth+1)
+	case len(schema.AllOf) > 0:
+		example := make(map[string]interface{})
+
+		for _, elementSchemaRef := range schema.AllOf {
+			if element, ok := synthesizeExample(elementSchemaRef, depth+1).(map[string]interface{}); ok {
+				for name, value := range element {
+					example[name] = value
+				}
+			}
+		}
+
+		return example
+	}
+
+	switch schema.Type {
+	case "string":
+		return stringExample(schema.Format)
+	case "integer":
+		if schema.Min != nil {
+			return int64(*schema.Min)
+		}
+
+		return 0
+	case "number":
+		if schema.Min != nil {
+			return *schema.Min
+		}
+
+		return 0.0
+	case "boolean":
+		return false
+	case "array":
+		item := synthesizeExample(schema.Items, depth+1)
+		if item == nil {
+			return []interface{}{}
+		}
+
+		return []interface{}{item}
+	}
+
+	example := make(map[string]interface{})
+
+	names := make([]string, 0, len(schema.Properties))
+	for name := range schema.Properties {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		if value := synthesizeExample(schema.Properties[name], depth+1); value != nil {
+			example[name] = value
+		}
+	}
+
+	return example
+}
+
+func stringExample(format string) string {
+	switch format {
+	case "date-time":
+		return "2006-01-02T15:04:05Z"
+	case "date":
+		return "2006-01-02"
+	case "time":
+		return "15:04:05"
+	case "email":
+		return "user@example.com"
+	case "uuid":
+		return "00000000-0000-0000-0000-000000000000"
+	case "uri", "url":
+		return "https://example.com"
+	case "hostname":
+		return "example.com"
+	case "ipv4":
+		return "127.0.0.1"
+	case "ipv6":
+		return "::1"
+	case "byte":
+		return "c3RyaW5n"
+	}
+
+	return "string"
+}
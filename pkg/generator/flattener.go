@@ -1,61 +1,152 @@
 package generator
 
 import (
+	"fmt"
+	"sort"
+	"strings"
+
 	spec3 "github.com/getkin/kin-openapi/openapi3"
 )
 
+type ModelCollision struct {
+	Name   string
+	Origin string
+	Other  string
+
+	schema *spec3.Schema
+}
+
+type modelOrigin struct {
+	pointer string
+	schema  *spec3.Schema
+}
+
 type Flattener struct {
-	doc *spec3.T
+	doc        *spec3.T
+	naming     Naming
+	origins    map[string]modelOrigin
+	collisions []ModelCollision
 }
 
-func NewFlattener(doc *spec3.T) *Flattener {
-	return &Flattener{doc: doc}
+func NewFlattener(doc *spec3.T, naming Naming) *Flattener {
+	return &Flattener{doc: doc, naming: naming}
 }
 
+// Flatten collects every model by its name. When two different schemas claim the same name the first one wins
+// and the collision is kept for Collisions.
 func (f *Flattener) Flatten() map[string]*spec3.SchemaRef {
 	flatSchemaRefs := make(map[string]*spec3.SchemaRef)
 
-	for schemaName, schema := range f.doc.Components.Schemas {
-		f.collectCustomSchemaRef("", schemaName, schema, flatSchemaRefs)
+	f.origins = make(map[string]modelOrigin)
+	f.collisions = nil
+
+	schemaNames := make([]string, 0, len(f.doc.Components.Schemas))
+	for schemaName := range f.doc.Components.Schemas {
+		schemaNames = append(schemaNames, schemaName)
+	}
+
+	sort.Strings(schemaNames)
+
+	for _, schemaName := range schemaNames {
+		pointer := componentSchemasPrefix + escapePointerToken(schemaName)
+		f.collectCustomSchemaRef("", schemaName, f.doc.Components.Schemas[schemaName], pointer, flatSchemaRefs)
+	}
+
+	for _, schemaName := range schemaNames {
+		pointer := componentSchemasPrefix + escapePointerToken(schemaName)
+		f.collectDeepCustomPropsSchemaRef(schemaName, f.doc.Components.Schemas[schemaName], pointer, flatSchemaRefs)
+	}
+
+	for _, op := range listOperations(f.doc, f.naming.initialisms) {
+		for _, form := range listOperationForms(op) {
+			pointer := op.Pointer + "/requestBody/content/" + escapePointerToken(form.ContentType) + "/schema"
+			f.collectOperationSchemaRef(form.Name, "body", form.MediaType.Schema, pointer, flatSchemaRefs)
+		}
+
+		for _, stream := range listOperationStreams(op) {
+			pointer := op.Pointer + "/responses/" + stream.Code + "/content/" + escapePointerToken(stream.ContentType) + "/schema"
+			f.collectOperationSchemaRef(stream.Name, "item", stream.MediaType.Schema, pointer, flatSchemaRefs)
+		}
+	}
+
+	for _, problem := range listProblemResponses(f.doc, f.naming.initialisms) {
+		f.collectOperationSchemaRef(problem.Operation.Name, problem.Code+"Problem", problem.Schema, problem.Pointer, flatSchemaRefs)
 	}
 
-	for schemaName, schema := range f.doc.Components.Schemas {
-		f.collectDeepCustomPropsSchemaRef(schemaName, schema, flatSchemaRefs)
+	for _, callback := range listCallbacks(f.doc, f.naming.initialisms) {
+		if mediaType := getJSONMediaType(callback.Operation); mediaType != nil {
+			f.collectOperationSchemaRef(callback.Name, "body", mediaType.Schema, callback.Pointer+"/requestBody", flatSchemaRefs)
+		}
 	}
 
 	return flatSchemaRefs
 }
 
-func (f *Flattener) collectDeepCustomPropsSchemaRef(schemaName string, schemaRef *spec3.SchemaRef, flatSchemaRefs map[string]*spec3.SchemaRef) {
+// Collisions lists the names claimed by more than one schema during the last Flatten.
+func (f *Flattener) Collisions() []ModelCollision {
+	return f.collisions
+}
+
+func (f *Flattener) collectOperationSchemaRef(
+	operationName string,
+	name string,
+	schemaRef *spec3.SchemaRef,
+	pointer string,
+	flatSchemaRefs map[string]*spec3.SchemaRef,
+) {
+	schemaName := f.collectCustomSchemaRef(operationName, name, schemaRef, pointer, flatSchemaRefs)
+	if schemaName != "" {
+		f.collectDeepCustomPropsSchemaRef(schemaName, schemaRef, pointer, flatSchemaRefs)
+	}
+}
+
+func (f *Flattener) collectDeepCustomPropsSchemaRef(
+	schemaName string,
+	schemaRef *spec3.SchemaRef,
+	pointer string,
+	flatSchemaRefs map[string]*spec3.SchemaRef,
+) {
 	custom := getCustomTypeSchemaRef(schemaRef)
 	if custom == nil {
 		return
 	}
 
 	var manyRefs []*spec3.SchemaRef
+	var manyKey string
 
 	if schemaRef.Value.AllOf != nil {
-		manyRefs = schemaRef.Value.AllOf
+		manyRefs, manyKey = schemaRef.Value.AllOf, "allOf"
 	}
 
 	if schemaRef.Value.OneOf != nil {
-		manyRefs = schemaRef.Value.OneOf
+		manyRefs, manyKey = schemaRef.Value.OneOf, "oneOf"
 	}
 
 	if schemaRef.Value.AnyOf != nil {
-		manyRefs = schemaRef.Value.AnyOf
+		manyRefs, manyKey = schemaRef.Value.AnyOf, "anyOf"
+	}
+
+	propNames := make([]string, 0, len(custom.Value.Properties))
+	for propName := range custom.Value.Properties {
+		propNames = append(propNames, propName)
 	}
 
-	for propName, propSchema := range custom.Value.Properties {
-		propSchemaName := f.collectCustomSchemaRef(schemaName, propName, propSchema, flatSchemaRefs)
+	sort.Strings(propNames)
+
+	for _, propName := range propNames {
+		propSchema := custom.Value.Properties[propName]
+		propPointer := pointer + "/properties/" + escapePointerToken(propName)
+
+		propSchemaName := f.collectCustomSchemaRef(schemaName, propName, propSchema, propPointer, flatSchemaRefs)
 		if propSchemaName != "" {
-			f.collectDeepCustomPropsSchemaRef(propSchemaName, propSchema, flatSchemaRefs)
+			f.collectDeepCustomPropsSchemaRef(propSchemaName, propSchema, propPointer, flatSchemaRefs)
 		}
 	}
 
 	if manyRefs != nil && len(manyRefs) > 1 {
-		for _, elementSchema := range manyRefs {
-			f.collectDeepCustomPropsSchemaRef(schemaName, elementSchema, flatSchemaRefs)
+		for i, elementSchema := range manyRefs {
+			elementPointer := fmt.Sprintf("%s/%s/%d", pointer, manyKey, i)
+			f.collectDeepCustomPropsSchemaRef(schemaName, elementSchema, elementPointer, flatSchemaRefs)
 		}
 	}
 }
@@ -64,6 +155,7 @@ func (f *Flattener) collectCustomSchemaRef(
 	parentName string,
 	name string,
 	schema *spec3.SchemaRef,
+	pointer string,
 	flatSchemaRefs map[string]*spec3.SchemaRef,
 ) string {
 	custom := getCustomTypeSchemaRef(schema)
@@ -71,18 +163,28 @@ func (f *Flattener) collectCustomSchemaRef(
 		return ""
 	}
 
-	var modelName string
+	modelName := customSchemaModelName(parentName, name, custom, f.naming.initialisms)
 
-	if custom.Ref != "" {
-		modelName = refToModelName(custom.Ref)
-	} else {
-		if parentName != "" {
-			modelName = embeddedObjectToModelName(parentName, name)
-		} else {
-			modelName = propToModelName(name)
+	if custom.Ref != "" && strings.HasPrefix(custom.Ref, componentSchemasPrefix) {
+		pointer = custom.Ref
+	}
+
+	if origin, ok := f.origins[modelName]; ok {
+		if origin.schema != custom.Value {
+			f.collisions = append(f.collisions, ModelCollision{
+				Name:   modelName,
+				Origin: origin.pointer,
+				Other:  pointer,
+				schema: custom.Value,
+			})
+
+			return ""
 		}
+
+		return modelName
 	}
 
+	f.origins[modelName] = modelOrigin{pointer: pointer, schema: custom.Value}
 	flatSchemaRefs[modelName] = custom
 
 	return modelName
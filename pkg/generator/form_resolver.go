@@ -0,0 +1,208 @@
+package generator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/iancoleman/strcase"
+	"github.com/pkg/errors"
+
+	spec3 "github.com/getkin/kin-openapi/openapi3"
+)
+
+type FormField struct {
+	Name        string
+	GoName      string
+	ContentType string
+	Headers     []string
+	Style       string
+	Explode     bool
+	Object      bool
+	Properties  []FormProperty
+}
+
+type FormProperty struct {
+	Name   string
+	GoName string
+}
+
+type FormBody struct {
+	Name        string
+	VarName     string
+	ModelName   string
+	IsMultipart bool
+	Fields      []FormField
+}
+
+type FormResolver struct {
+	doc      *spec3.T
+	naming   Naming
+	models   map[string]*Model
+	warnings []string
+}
+
+func NewFormResolver(doc *spec3.T, models map[string]*Model, naming Naming) *FormResolver {
+	return &FormResolver{
+		doc:    doc,
+		models: models,
+		naming: naming,
+	}
+}
+
+func (r *FormResolver) Resolve() ([]*FormBody, error) {
+	forms := make([]*FormBody, 0)
+	extra := r.naming.initialisms
+
+	for _, op := range listOperations(r.doc, extra) {
+		for _, form := range listOperationForms(op) {
+			if isArray(form.MediaType.Schema.Value.Type) {
+				continue
+			}
+
+			custom := getCustomTypeSchemaRef(form.MediaType.Schema)
+			if custom == nil {
+				continue
+			}
+
+			modelName := customSchemaModelName(form.Name, "body", custom, extra)
+
+			model, ok := r.models[modelName]
+			if !ok {
+				continue
+			}
+
+			fields, err := r.buildFields(op.Name, model, form.MediaType.Encoding, form.IsMultipart)
+			if err != nil {
+				return nil, errors.Wrapf(err, "form body of operation %s", op.Name)
+			}
+
+			forms = append(forms, &FormBody{
+				Name:        form.Name,
+				VarName:     strcase.ToLowerCamel(form.Name) + "Form",
+				ModelName:   modelName,
+				IsMultipart: form.IsMultipart,
+				Fields:      fields,
+			})
+		}
+	}
+
+	return forms, nil
+}
+
+// Warnings lists the required encoding headers of the last Resolve set on multipart parts that aren't binary, a
+// plain value has no header to carry them so they are ignored.
+func (r *FormResolver) Warnings() []string {
+	return r.warnings
+}
+
+func (r *FormResolver) buildFields(opName string, model *Model, encodings map[string]*spec3.Encoding, isMultipart bool) ([]FormField, error) {
+	fields := make([]FormField, 0, len(model.Props))
+
+	for _, prop := range model.Props {
+		field := FormField{
+			Name:        prop.SpecName,
+			GoName:      prop.Name,
+			ContentType: defaultPartContentType(prop.Schema),
+			Style:       spec3.SerializationForm,
+			Explode:     true,
+		}
+
+		if encoding := encodings[prop.SpecName]; encoding != nil {
+			if encoding.ContentType != "" {
+				field.ContentType = strings.TrimSpace(strings.Split(encoding.ContentType, ",")[0])
+			}
+
+			if encoding.Style != "" {
+				field.Style = encoding.Style
+			}
+
+			if encoding.Explode != nil {
+				field.Explode = *encoding.Explode
+			}
+
+			for name, header := range encoding.Headers {
+				if header.Value != nil && header.Value.Required {
+					field.Headers = append(field.Headers, name)
+				}
+			}
+
+			sort.Strings(field.Headers)
+		}
+
+		isFile := strings.TrimLeft(prop.GoType.Name, "[]*") == FileTypeName
+
+		if isMultipart && len(field.Headers) > 0 && !isFile {
+			r.warnings = append(r.warnings, fmt.Sprintf(
+				"required encoding headers %s of part %s of operation %s are ignored, only binary parts carry headers",
+				strings.Join(field.Headers, ", "), field.Name, opName,
+			))
+
+			field.Headers = nil
+		}
+
+		if !isMultipart {
+			if err := r.resolveObjectField(&field, &prop); err != nil {
+				return nil, err
+			}
+		}
+
+		fields = append(fields, field)
+	}
+
+	sort.Slice(fields, func(i, j int) bool {
+		return fields[i].Name < fields[j].Name
+	})
+
+	return fields, nil
+}
+
+// resolveObjectField lists the properties an urlencoded object field is serialized into, following its style and
+// explode encoding.
+func (r *FormResolver) resolveObjectField(field *FormField, prop *Prop) error {
+	isObject := prop.Schema.Type == "object" || len(prop.Schema.Properties) > 0
+
+	if !isObject {
+		if field.Style == spec3.SerializationDeepObject {
+			return errors.Errorf("field %s: the deepObject style only applies to objects", field.Name)
+		}
+
+		return nil
+	}
+
+	if field.Style != spec3.SerializationForm && field.Style != spec3.SerializationDeepObject {
+		return errors.Errorf("field %s: the %s style doesn't apply to objects", field.Name, field.Style)
+	}
+
+	field.Object = true
+
+	model, ok := r.models[strings.TrimLeft(prop.GoType.Name, "*")]
+	if !ok {
+		return nil
+	}
+
+	for _, property := range model.Props {
+		field.Properties = append(field.Properties, FormProperty{
+			Name:   property.SpecName,
+			GoName: property.Name,
+		})
+	}
+
+	return nil
+}
+
+func defaultPartContentType(schema *spec3.Schema) string {
+	if schema.Type == "array" && schema.Items != nil {
+		schema = schema.Items.Value
+	}
+
+	if isBinary(schema) {
+		return "application/octet-stream"
+	}
+
+	if isScalar(schema.Type) {
+		return ""
+	}
+
+	return "application/json"
+}
@@ -9,71 +9,249 @@ import (
 	"path/filepath"
 	"reflect"
 	"sort"
+	"strings"
 	"text/template"
 
 	"github.com/pkg/errors"
+
+	spec3 "github.com/getkin/kin-openapi/openapi3"
+)
+
+const (
+	SpecFilename = "openapi.json"
 )
 
 var (
-	structTemplate *template.Template
+	structTemplate     *template.Template
+	formTemplate       *template.Template
+	streamTemplate     *template.Template
+	callbackTemplate   *template.Template
+	urlTemplate        *template.Template
+	mockTemplate       *template.Template
+	problemTemplate    *template.Template
+	paginationTemplate *template.Template
+	asyncTemplate      *template.Template
+	authTemplate       *template.Template
+	contentTemplate    *template.Template
+	serverTemplate     *template.Template
+	clientTemplate     *template.Template
+	fakeClientTemplate *template.Template
+	routerTemplates    map[string]*template.Template
+	specTemplates      map[string]*template.Template
 )
 
-func ReadTemplates(structTmplPath string) error {
-	tplBytes, err := os.ReadFile(structTmplPath)
+var specTemplateNames = []string{"spec", "response_validation", "security", "recording"}
+
+var templateFuncs = template.FuncMap{
+	"Join": strings.Join,
+	"NotNil": func(v interface{}) bool {
+		reflval := reflect.ValueOf(v)
+		return !reflval.IsNil()
+	},
+	"Deref": func(v interface{}) interface{} {
+		reflval := reflect.ValueOf(v)
+
+		if !reflval.IsValid() || reflval.IsNil() {
+			return nil
+		}
+
+		if reflval.Kind() == reflect.Ptr {
+			elem := reflval.Elem()
+			return elem.Interface()
+		}
+
+		return v
+	},
+}
+
+func ReadTemplates(templatesFolder string) error {
+	var err error
+
+	structTemplate, err = readTemplate(templatesFolder, "struct")
+	if err != nil {
+		return err
+	}
+
+	formTemplate, err = readTemplate(templatesFolder, "form")
+	if err != nil {
+		return err
+	}
+
+	streamTemplate, err = readTemplate(templatesFolder, "stream")
+	if err != nil {
+		return err
+	}
+
+	callbackTemplate, err = readTemplate(templatesFolder, "callback")
+	if err != nil {
+		return err
+	}
+
+	urlTemplate, err = readTemplate(templatesFolder, "url")
+	if err != nil {
+		return err
+	}
+
+	mockTemplate, err = readTemplate(templatesFolder, "mock")
 	if err != nil {
 		return err
 	}
 
-	structTemplate, err = template.New("struct").Funcs(template.FuncMap{
-		"NotNil": func(v interface{}) bool {
-			reflval := reflect.ValueOf(v)
-			return !reflval.IsNil()
-		},
-		"Deref": func(v interface{}) interface{} {
-			reflval := reflect.ValueOf(v)
+	problemTemplate, err = readTemplate(templatesFolder, "problem")
+	if err != nil {
+		return err
+	}
 
-			if !reflval.IsValid() || reflval.IsNil() {
-				return nil
-			}
+	paginationTemplate, err = readTemplate(templatesFolder, "pagination")
+	if err != nil {
+		return err
+	}
 
-			if reflval.Kind() == reflect.Ptr {
-				elem := reflval.Elem()
-				return elem.Interface()
-			}
+	asyncTemplate, err = readTemplate(templatesFolder, "async")
+	if err != nil {
+		return err
+	}
 
-			return v
-		},
-	}).Parse(string(tplBytes))
+	authTemplate, err = readTemplate(templatesFolder, "auth")
 	if err != nil {
 		return err
 	}
 
+	contentTemplate, err = readTemplate(templatesFolder, "content")
+	if err != nil {
+		return err
+	}
+
+	serverTemplate, err = readTemplate(templatesFolder, "server")
+	if err != nil {
+		return err
+	}
+
+	clientTemplate, err = readTemplate(templatesFolder, "client")
+	if err != nil {
+		return err
+	}
+
+	fakeClientTemplate, err = readTemplate(templatesFolder, "fake_client")
+	if err != nil {
+		return err
+	}
+
+	routerTemplates = make(map[string]*template.Template)
+
+	for _, router := range Routers {
+		routerTemplates[router], err = readTemplate(templatesFolder, "router_"+router)
+		if err != nil {
+			return err
+		}
+	}
+
+	specTemplates = make(map[string]*template.Template)
+
+	for _, name := range specTemplateNames {
+		specTemplates[name], err = readTemplate(templatesFolder, name)
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
-type sortingProp struct {
-	props *[]Prop
+func readTemplate(templatesFolder string, name string) (*template.Template, error) {
+	tplBytes, err := os.ReadFile(filepath.Join(templatesFolder, name+".tmpl"))
+	if err != nil {
+		return nil, err
+	}
+
+	return template.New(name).Funcs(templateFuncs).Parse(string(tplBytes))
 }
 
-func (p *sortingProp) Len() int {
-	return len(*p.props)
+type SpecModel struct {
+	PkgName         string
+	SpecFilename    string
+	SecuritySchemes []SecuritySchemeModel
+	ProblemModel    string
 }
 
-func (p *sortingProp) Less(i, j int) bool {
-	props := *p.props
-	return props[i].Name > props[j].Name
+type FormsModel struct {
+	PkgName    string
+	FormBodies []*FormBody
 }
 
-func (p *sortingProp) Swap(i, j int) {
-	props := *p.props
-	props[i], props[j] = props[j], props[i]
+type StreamsModel struct {
+	PkgName string
+	Streams []*StreamModel
+}
+
+// CallbacksModel declares HTTPRequestDoer for the senders when there is no client.go to declare it, as with a spec of
+// webhooks only.
+type CallbacksModel struct {
+	PkgName            string
+	Callbacks          []*CallbackModel
+	DeclareRequestDoer bool
+}
+
+type URLsModel struct {
+	PkgName         string
+	ServerURL       string
+	ServerVariables []ServerVariableModel
+	URLs            []*URLModel
+}
+
+type MockModel struct {
+	PkgName    string
+	Operations []*MockOperation
+}
+
+type ProblemsModel struct {
+	PkgName    string
+	Operations []*ProblemOperation
+}
+
+type PaginationsModel struct {
+	PkgName     string
+	Paginations []*PaginationModel
+}
+
+type AsyncModel struct {
+	PkgName  string
+	Channels []*AsyncChannelModel
+}
+
+type ServerModel struct {
+	PkgName    string
+	Operations []*ServerOperation
+}
+
+type ClientModel struct {
+	PkgName    string
+	Operations []*ServerOperation
+	Problems   map[string]bool
+}
+
+type ContentModel struct {
+	PkgName    string
+	Operations []*ContentOperation
+}
+
+type AuthModel struct {
+	PkgName         string
+	SecuritySchemes []SecuritySchemeModel
 }
 
 type Generator struct {
+	PropertyOrder PropertyOrder
+
+	naming Naming
+	files  map[string]string
 }
 
-func NewGenerator() *Generator {
-	return &Generator{}
+func NewGenerator(naming Naming) *Generator {
+	return &Generator{
+		naming: naming,
+		files:  make(map[string]string),
+	}
 }
 
 func (g *Generator) GenerateForModel(writer io.Writer, model *Model) error {
@@ -84,18 +262,245 @@ func (g *Generator) GenerateForModel(writer io.Writer, model *Model) error {
 	return nil
 }
 
-func (g *Generator) GenerateToFile(models map[string]*Model, path string) error {
-	for name, model := range models {
-		sorted := &sortingProp{
-			props: &model.Props,
+func (g *Generator) GenerateSpecToFile(doc *spec3.T, path string) error {
+	specBytes, err := NewBundler(doc).Bundle()
+	if err != nil {
+		return errors.Wrapf(err, "failed while bundling openapi spec")
+	}
+
+	fmt.Printf("Generating: %s\n", SpecFilename)
+
+	if err := g.claimFile(filepath.Join(path, SpecFilename), "the embedded spec"); err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(filepath.Join(path, SpecFilename), specBytes, 0666); err != nil {
+		return err
+	}
+
+	model := &SpecModel{
+		PkgName:         GeneratedFilesPkgName,
+		SpecFilename:    SpecFilename,
+		SecuritySchemes: resolveSecuritySchemes(doc, g.naming.initialisms),
+		ProblemModel:    primaryProblemModelName(doc, g.naming.initialisms),
+	}
+
+	for _, name := range specTemplateNames {
+		if err := g.executeToFile(specTemplates[name], model, filepath.Join(path, name+".go")); err != nil {
+			return err
 		}
+	}
+
+	return nil
+}
+
+func (g *Generator) GenerateFormsToFile(forms []*FormBody, models map[string]*Model, path string) error {
+	if len(forms) == 0 && !usesFileType(models) {
+		return nil
+	}
+
+	model := &FormsModel{
+		PkgName:    GeneratedFilesPkgName,
+		FormBodies: forms,
+	}
+
+	return g.executeToFile(formTemplate, model, filepath.Join(path, "form.go"))
+}
+
+func (g *Generator) GenerateStreamsToFile(streams []*StreamModel, path string) error {
+	if len(streams) == 0 {
+		return nil
+	}
+
+	model := &StreamsModel{
+		PkgName: GeneratedFilesPkgName,
+		Streams: streams,
+	}
+
+	return g.executeToFile(streamTemplate, model, filepath.Join(path, "stream.go"))
+}
+
+func (g *Generator) GenerateCallbacksToFile(doc *spec3.T, callbacks []*CallbackModel, path string) error {
+	if len(callbacks) == 0 {
+		return nil
+	}
+
+	model := &CallbacksModel{
+		PkgName:            GeneratedFilesPkgName,
+		Callbacks:          callbacks,
+		DeclareRequestDoer: len(listOperations(doc, nil)) == 0,
+	}
+
+	return g.executeToFile(callbackTemplate, model, filepath.Join(path, "callback.go"))
+}
+
+func (g *Generator) GenerateURLsToFile(doc *spec3.T, urls []*URLModel, path string) error {
+	if len(urls) == 0 {
+		return nil
+	}
+
+	model := &URLsModel{
+		PkgName: GeneratedFilesPkgName,
+		URLs:    urls,
+	}
+
+	model.ServerURL, model.ServerVariables = resolveServer(doc)
+
+	return g.executeToFile(urlTemplate, model, filepath.Join(path, "url.go"))
+}
+
+func (g *Generator) GenerateMockToFile(operations []*MockOperation, path string) error {
+	if len(operations) == 0 {
+		return nil
+	}
+
+	model := &MockModel{
+		PkgName:    GeneratedFilesPkgName,
+		Operations: operations,
+	}
+
+	return g.executeToFile(mockTemplate, model, filepath.Join(path, "mock.go"))
+}
+
+func (g *Generator) GenerateProblemsToFile(operations []*ProblemOperation, path string) error {
+	if len(operations) == 0 {
+		return nil
+	}
+
+	model := &ProblemsModel{
+		PkgName:    GeneratedFilesPkgName,
+		Operations: operations,
+	}
+
+	return g.executeToFile(problemTemplate, model, filepath.Join(path, "problem_response.go"))
+}
+
+func (g *Generator) GeneratePaginationsToFile(paginations []*PaginationModel, path string) error {
+	if len(paginations) == 0 {
+		return nil
+	}
+
+	model := &PaginationsModel{
+		PkgName:     GeneratedFilesPkgName,
+		Paginations: paginations,
+	}
+
+	return g.executeToFile(paginationTemplate, model, filepath.Join(path, "pagination.go"))
+}
+
+func (g *Generator) GenerateAsyncToFile(channels []*AsyncChannelModel, path string) error {
+	if len(channels) == 0 {
+		return nil
+	}
+
+	model := &AsyncModel{
+		PkgName:  GeneratedFilesPkgName,
+		Channels: channels,
+	}
 
-		sort.Sort(sorted)
+	return g.executeToFile(asyncTemplate, model, filepath.Join(path, "async.go"))
+}
+
+func (g *Generator) GenerateAuthToFile(doc *spec3.T, path string) error {
+	schemes := resolveSecuritySchemes(doc, g.naming.initialisms)
+	if len(schemes) == 0 {
+		return nil
+	}
+
+	model := &AuthModel{
+		PkgName:         GeneratedFilesPkgName,
+		SecuritySchemes: schemes,
+	}
+
+	return g.executeToFile(authTemplate, model, filepath.Join(path, "auth.go"))
+}
+
+func (g *Generator) GenerateServerToFile(operations []*ServerOperation, router string, path string) error {
+	if err := ValidateRouter(router); err != nil {
+		return err
+	}
+
+	if len(operations) == 0 {
+		return nil
+	}
+
+	model := &ServerModel{
+		PkgName:    GeneratedFilesPkgName,
+		Operations: operations,
+	}
+
+	if err := g.executeToFile(serverTemplate, model, filepath.Join(path, "server.go")); err != nil {
+		return err
+	}
+
+	if router == "" {
+		return nil
+	}
+
+	return g.executeToFile(routerTemplates[router], model, filepath.Join(path, "router.go"))
+}
+
+func (g *Generator) GenerateClientToFile(operations []*ServerOperation, problems []*ProblemOperation, path string) error {
+	if len(operations) == 0 {
+		return nil
+	}
+
+	model := &ClientModel{
+		PkgName:    GeneratedFilesPkgName,
+		Operations: operations,
+		Problems:   make(map[string]bool),
+	}
+
+	for _, problem := range problems {
+		model.Problems[problem.Name] = true
+	}
+
+	if err := g.executeToFile(clientTemplate, model, filepath.Join(path, "client.go")); err != nil {
+		return err
+	}
+
+	return g.executeToFile(fakeClientTemplate, model, filepath.Join(path, "fake_client.go"))
+}
+
+func (g *Generator) GenerateContentToFile(doc *spec3.T, operations []*ContentOperation, path string) error {
+	if !usesContent(doc) {
+		return nil
+	}
+
+	model := &ContentModel{
+		PkgName:    GeneratedFilesPkgName,
+		Operations: operations,
+	}
+
+	return g.executeToFile(contentTemplate, model, filepath.Join(path, "content.go"))
+}
+
+func (g *Generator) GenerateToFile(models map[string]*Model, path string) error {
+	names := make([]string, 0, len(models))
+	for name := range models {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		model := models[name]
+
+		if g.PropertyOrder == PropertyOrderAlphabetical {
+			props := model.Props
+			sort.SliceStable(props, func(i, j int) bool {
+				return props[i].Name < props[j].Name
+			})
+		}
 
 		filename := modelToFilename(name) + ".go"
 
 		fmt.Printf("Generating: %s\n", filename)
 
+		if err := g.claimFile(filepath.Join(path, filename), "model "+name); err != nil {
+			return err
+		}
+
 		file, err := os.Create(filepath.Join(path, filename))
 		if err != nil {
 			return err
@@ -125,3 +530,43 @@ func (g *Generator) GenerateToFile(models map[string]*Model, path string) error
 
 	return nil
 }
+
+func (g *Generator) executeToFile(tmpl *template.Template, data interface{}, filename string) error {
+	fmt.Printf("Generating: %s\n", filepath.Base(filename))
+
+	if err := g.claimFile(filename, "the "+tmpl.Name()+" helpers"); err != nil {
+		return err
+	}
+
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	fileWriter := bufio.NewWriter(file)
+
+	if err := tmpl.Execute(fileWriter, data); err != nil {
+		return err
+	}
+
+	return fileWriter.Flush()
+}
+
+// claimFile fails when a helper or model generated earlier in the run has already been written into filename,
+// which would otherwise be silently overwritten.
+func (g *Generator) claimFile(filename string, owner string) error {
+	if g.files == nil {
+		g.files = make(map[string]string)
+	}
+
+	filename = filepath.Clean(filename)
+
+	if other, ok := g.files[filename]; ok {
+		return errors.Errorf("%s and %s are both generated into %s", other, owner, filepath.Base(filename))
+	}
+
+	g.files[filename] = owner
+
+	return nil
+}
@@ -0,0 +1,189 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// jsonSchemaKeywords tell a JSON Schema document without `$schema` apart from other YAML or JSON files.
+var jsonSchemaKeywords = []string{"type", "properties", "$defs", "definitions", "allOf", "anyOf", "oneOf", "$ref"}
+
+func isJSONSchema(doc map[string]interface{}) bool {
+	_, isOpenAPI := doc["openapi"]
+	_, isSwagger := doc["swagger"]
+
+	if isOpenAPI || isSwagger || isAsyncAPI(doc) {
+		return false
+	}
+
+	if _, ok := doc["$schema"]; ok {
+		return true
+	}
+
+	for _, keyword := range jsonSchemaKeywords {
+		if _, ok := doc[keyword]; ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+type jsonSchemaFile struct {
+	path       string
+	schema     map[string]interface{}
+	normalizer *openAPI31Normalizer
+}
+
+// jsonSchemasToOpenAPI wraps standalone JSON Schema documents (draft-07 or 2020-12) into an OpenAPI document:
+// every root schema and all of its `definitions`/`$defs` become component schemas.
+func jsonSchemasToOpenAPI(paths []string, extra initialisms) (map[string]interface{}, error) {
+	schemas := make(map[string]interface{})
+	files := make(map[string]*jsonSchemaFile)
+	order := make([]*jsonSchemaFile, 0, len(paths))
+
+	for _, path := range paths {
+		canonicalPath, err := filepath.Abs(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, ok := files[canonicalPath]; ok {
+			return nil, errors.Errorf("%s is given more than once", path)
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		schema, err := readRawDocument(data)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed while reading %s", path)
+		}
+
+		if !isJSONSchema(schema) {
+			return nil, errors.Errorf("%s is not a JSON Schema document", path)
+		}
+
+		file := &jsonSchemaFile{
+			path:   canonicalPath,
+			schema: schema,
+			normalizer: &openAPI31Normalizer{
+				schemas:     schemas,
+				hoisted:     make(map[string]interface{}),
+				renamed:     make(map[string]string),
+				initialisms: extra,
+			},
+		}
+
+		rootName := jsonSchemaRootName(path, schema, extra)
+		file.normalizer.root = componentSchemasPrefix + rootName
+
+		delete(schema, "$schema")
+		delete(schema, "$id")
+
+		file.normalizer.normalizeSchema(schema, "#")
+		file.normalizer.flushHoisted()
+
+		if hasSchemaKeywords(schema) {
+			if _, taken := schemas[rootName]; taken {
+				return nil, errors.Errorf("schema %s of %s is already defined", rootName, path)
+			}
+
+			schemas[rootName] = schema
+		}
+
+		files[file.path] = file
+		order = append(order, file)
+	}
+
+	for _, file := range order {
+		rewriteJSONSchemaRefs(file, files, file.schema)
+
+		for _, hoisted := range file.normalizer.hoisted {
+			rewriteJSONSchemaRefs(file, files, hoisted)
+		}
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":   "JSON Schema",
+			"version": "1.0.0",
+		},
+		"paths": map[string]interface{}{},
+		"components": map[string]interface{}{
+			"schemas": schemas,
+		},
+	}, nil
+}
+
+func rewriteJSONSchemaRefs(file *jsonSchemaFile, files map[string]*jsonSchemaFile, node interface{}) {
+	switch value := node.(type) {
+	case map[string]interface{}:
+		if ref, ok := value["$ref"].(string); ok {
+			target, pointer := file, ref
+
+			if i := strings.Index(ref, "#"); i != 0 {
+				fileName, fragment := ref, "#"
+				if i > 0 {
+					fileName, fragment = ref[:i], ref[i:]
+				}
+
+				if other, ok := files[resolveJSONSchemaPath(file.path, fileName)]; ok {
+					target, pointer = other, fragment
+				} else {
+					target = nil
+				}
+			}
+
+			if target != nil {
+				value["$ref"] = target.normalizer.rewriteRef(pointer)
+			}
+		}
+
+		for _, child := range value {
+			rewriteJSONSchemaRefs(file, files, child)
+		}
+	case []interface{}:
+		for _, item := range value {
+			rewriteJSONSchemaRefs(file, files, item)
+		}
+	}
+}
+
+// resolveJSONSchemaPath resolves a file referenced from the file at path, relative references start from its
+// directory.
+func resolveJSONSchemaPath(path string, ref string) string {
+	if filepath.IsAbs(ref) {
+		return filepath.Clean(ref)
+	}
+
+	return filepath.Join(filepath.Dir(path), filepath.FromSlash(ref))
+}
+
+func jsonSchemaRootName(path string, schema map[string]interface{}, extra initialisms) string {
+	if title, ok := schema["title"].(string); ok && title != "" {
+		return goIdentifier(title, extra)
+	}
+
+	base := filepath.Base(path)
+
+	return goIdentifier(strings.TrimSuffix(strings.TrimSuffix(base, filepath.Ext(base)), ".schema"), extra)
+}
+
+func hasSchemaKeywords(schema map[string]interface{}) bool {
+	for key := range schema {
+		switch key {
+		case "title", "description", "$comment":
+		default:
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,104 @@
+package generator
+
+import (
+	"encoding/json"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+
+	spec3 "github.com/getkin/kin-openapi/openapi3"
+)
+
+type LoadOptions struct {
+	// RefNames assigns model names to externally referenced schemas, keyed by file path optionally followed by a fragment.
+	RefNames map[string]string
+	// Naming turns the names of external schemas, JSON Schema definitions and AsyncAPI channels into identifiers.
+	Naming Naming
+}
+
+func LoadSpec(paths ...string) (*spec3.T, error) {
+	return LoadSpecWithOptions(LoadOptions{}, paths...)
+}
+
+// LoadSpecWithOptions loads the document at the first path, converting Swagger 2.0 and normalizing OpenAPI 3.1 input to 3.0 beforehand.
+// Standalone JSON Schema documents are accepted as well, in which case every path is loaded as a JSON Schema,
+// and so are AsyncAPI documents, whose message payloads and headers become component schemas.
+// Schemas referenced from other files become component schemas named after their component key, title or file name.
+func LoadSpecWithOptions(options LoadOptions, paths ...string) (*spec3.T, error) {
+	if len(paths) == 0 {
+		return nil, errors.New("no input provided")
+	}
+
+	path := paths[0]
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	l := spec3.NewLoader()
+	l.IsExternalRefsAllowed = true
+
+	extra := options.Naming.initialisms
+
+	raw, err := readRawDocument(data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed while reading %s", path)
+	}
+
+	switch {
+	case isJSONSchema(raw):
+		doc, err := jsonSchemasToOpenAPI(paths, extra)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed while converting json schema")
+		}
+
+		if data, err = json.Marshal(doc); err != nil {
+			return nil, err
+		}
+	case len(paths) > 1:
+		return nil, errors.New("only JSON Schema documents can be combined")
+	case isAsyncAPI(raw):
+		doc, err := asyncAPIToOpenAPI(raw, extra)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed while converting asyncapi spec")
+		}
+
+		if data, err = json.Marshal(doc); err != nil {
+			return nil, err
+		}
+	case raw["openapi"] == nil && !isSwagger2(raw):
+		return nil, errors.Errorf("%s is neither an OpenAPI, Swagger, AsyncAPI nor a JSON Schema document", path)
+	case isSwagger2(raw):
+		if data, err = json.Marshal(raw); err != nil {
+			return nil, err
+		}
+
+		if data, err = convertSwagger2(data); err != nil {
+			return nil, errors.Wrapf(err, "failed while converting swagger 2.0 spec")
+		}
+	default:
+		if err := resolveExternalRefs(raw, path, options.RefNames, extra); err != nil {
+			return nil, errors.Wrapf(err, "failed while resolving external refs")
+		}
+
+		if isOpenAPI31(raw) {
+			normalizeOpenAPI31(raw, extra)
+		}
+
+		if data, err = json.Marshal(raw); err != nil {
+			return nil, err
+		}
+	}
+
+	doc, err := l.LoadFromDataWithPath(data, &url.URL{Path: filepath.ToSlash(path)})
+	if err != nil {
+		return nil, err
+	}
+
+	liftWebhooks(doc)
+
+	return doc, nil
+}
@@ -0,0 +1,227 @@
+package generator
+
+import (
+	"encoding/json"
+	"mime"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	spec3 "github.com/getkin/kin-openapi/openapi3"
+)
+
+var pathParamRegexp = regexp.MustCompile(`\{[^/{}]+\}`)
+
+type MockExample struct {
+	Name string
+	Body string
+}
+
+// MockOperation answers the ServerInterface method of the operation with its examples. PathPattern captures the
+// PathParamNames in order, so the MockServer can serve requests without a router too. The examples of streams are
+// framed already, each item of an array example being an event or a line.
+type MockOperation struct {
+	*ServerOperation
+
+	PathPattern    string
+	PathParamNames []string
+	StatusCode     int
+	ContentType    string
+	DefaultExample string
+	Examples       []MockExample
+}
+
+type MockResolver struct {
+	doc    *spec3.T
+	naming Naming
+}
+
+func NewMockResolver(doc *spec3.T, naming Naming) *MockResolver {
+	return &MockResolver{
+		doc:    doc,
+		naming: naming,
+	}
+}
+
+func (r *MockResolver) Resolve() ([]*MockOperation, error) {
+	basePath := r.basePath()
+
+	serverOperations, err := NewServerResolver(r.doc, r.naming).Resolve()
+	if err != nil {
+		return nil, err
+	}
+
+	operations := make([]*MockOperation, 0)
+
+	for i, op := range listOperations(r.doc, r.naming.initialisms) {
+		mock := &MockOperation{
+			ServerOperation: serverOperations[i],
+			PathPattern:     mockPathPattern(basePath, op.Path),
+			StatusCode:      successStatusCode(op.Operation),
+		}
+
+		for _, param := range pathParamRegexp.FindAllString(op.Path, -1) {
+			mock.PathParamNames = append(mock.PathParamNames, strings.Trim(param, "{}"))
+		}
+
+		responseRef := op.Responses.Get(mock.StatusCode)
+		if responseRef == nil {
+			responseRef = op.Responses.Default()
+		}
+
+		if responseRef != nil && responseRef.Value != nil {
+			contentType, mediaType := mockMediaType(responseRef.Value.Content)
+			if mediaType != nil {
+				if err := buildMockExamples(mock, contentType, mediaType); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		operations = append(operations, mock)
+	}
+
+	return operations, nil
+}
+
+func (r *MockResolver) basePath() string {
+	serverURL, variables := resolveServer(r.doc)
+
+	for _, variable := range variables {
+		serverURL = strings.ReplaceAll(serverURL, "{"+variable.Name+"}", variable.Default)
+	}
+
+	parsed, err := url.Parse(serverURL)
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimRight(parsed.Path, "/")
+}
+
+func mockPathPattern(basePath string, path string) string {
+	pattern := "^"
+
+	if basePath != "" {
+		pattern += "(?:" + regexp.QuoteMeta(basePath) + ")?"
+	}
+
+	last := 0
+	for _, loc := range pathParamRegexp.FindAllStringIndex(path, -1) {
+		pattern += regexp.QuoteMeta(path[last:loc[0]]) + "([^/]+)"
+		last = loc[1]
+	}
+
+	return pattern + regexp.QuoteMeta(path[last:]) + "$"
+}
+
+func mockMediaType(content spec3.Content) (string, *spec3.MediaType) {
+	if mediaType := content.Get("application/json"); mediaType != nil {
+		return "application/json", mediaType
+	}
+
+	contentTypes := make([]string, 0, len(content))
+	for contentType := range content {
+		contentTypes = append(contentTypes, contentType)
+	}
+
+	if len(contentTypes) == 0 {
+		return "", nil
+	}
+
+	sort.Strings(contentTypes)
+
+	return contentTypes[0], content[contentTypes[0]]
+}
+
+func buildMockExamples(mock *MockOperation, contentType string, mediaType *spec3.MediaType) error {
+	mock.ContentType = contentType
+
+	names := make([]string, 0, len(mediaType.Examples))
+	for name, exampleRef := range mediaType.Examples {
+		if exampleRef.Value != nil && exampleRef.Value.Value != nil {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+
+	encode := encodeMockExample
+	if mediaTypeName, _, err := mime.ParseMediaType(contentType); err == nil &&
+		(mediaTypeName == EventStreamMediaType || mediaTypeName == NDJSONMediaType) {
+		encode = encodeMockStreamExample
+	}
+
+	for _, name := range names {
+		body, err := encode(contentType, mediaType.Examples[name].Value.Value)
+		if err != nil {
+			return err
+		}
+
+		mock.Examples = append(mock.Examples, MockExample{
+			Name: name,
+			Body: body,
+		})
+	}
+
+	var err error
+
+	switch {
+	case mediaType.Example != nil:
+		mock.DefaultExample, err = encode(contentType, mediaType.Example)
+	case len(mock.Examples) > 0:
+		mock.DefaultExample = mock.Examples[0].Body
+	default:
+		mock.DefaultExample, err = encode(contentType, schemaExample(mediaType.Schema))
+	}
+
+	return err
+}
+
+func encodeMockExample(contentType string, example interface{}) (string, error) {
+	isJSON := contentType == "application/json" || strings.HasSuffix(contentType, "+json")
+
+	if str, ok := example.(string); ok && !isJSON {
+		return str, nil
+	}
+
+	if example == nil && !isJSON {
+		return "", nil
+	}
+
+	body, err := json.Marshal(example)
+	if err != nil {
+		return "", err
+	}
+
+	return string(body), nil
+}
+
+// encodeMockStreamExample frames the items of a stream example as events or lines, an example that isn't an array
+// is a single item.
+func encodeMockStreamExample(contentType string, example interface{}) (string, error) {
+	items, ok := example.([]interface{})
+	if !ok {
+		items = []interface{}{example}
+	}
+
+	isEventStream := strings.HasPrefix(contentType, EventStreamMediaType)
+
+	var body strings.Builder
+
+	for _, item := range items {
+		data, err := json.Marshal(item)
+		if err != nil {
+			return "", err
+		}
+
+		if isEventStream {
+			body.WriteString("data: " + string(data) + "\n\n")
+		} else {
+			body.WriteString(string(data) + "\n")
+		}
+	}
+
+	return body.String(), nil
+}
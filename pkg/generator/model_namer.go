@@ -0,0 +1,393 @@
+package generator
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	spec3 "github.com/getkin/kin-openapi/openapi3"
+)
+
+const GoNameExtension = "x-go-name"
+
+type NamingStrategy string
+
+const (
+	NamingStrategyFail   NamingStrategy = "fail"
+	NamingStrategySuffix NamingStrategy = "suffix"
+	NamingStrategyPath   NamingStrategy = "path"
+)
+
+// helperIdentifiers are the exported package level names of the helpers that don't depend on the spec, by the file
+// they are generated into.
+var helperIdentifiers = map[string][]string{
+	"auth.go":             {"AuthTransport", "RequestEditorFn", "TokenSource"},
+	"client.go":           {"Client", "ClientInterface", "HTTPRequestDoer", "NewClient"},
+	"content.go":          {"MarshalContent", "UnmarshalContent"},
+	"fake_client.go":      {"FakeClient"},
+	"form.go":             {"FormFile"},
+	"mock.go":             {"MockExampleHeader", "MockServer", "NewMockServer"},
+	"problem_response.go": {"ProblemMediaType"},
+	"recording.go": {
+		"Interaction", "RecordedRequest", "RecordedResponse", "RecordingMode", "RecordingRecord", "RecordingReplay",
+		"RecordingTransport",
+	},
+	"response_validation.go": {
+		"ResponseValidationFail", "ResponseValidationLog", "ResponseValidationMiddleware", "ResponseValidationMode",
+		"ResponseValidationOptions", "ResponseValidationPanic", "ValidatingTransport",
+	},
+	"security.go": {
+		"AuthCredentials", "AuthCredentialsFromContext", "AuthenticationFunc", "Authenticator", "SecurityMiddleware",
+	},
+	"server.go": {
+		"InvalidParamError", "MiddlewareFunc", "Routes", "ServerInterface", "ServerOptions", "ServerRoute",
+		"WithPathParams",
+	},
+	"spec.go": {"GetSpec", "ValidationMiddleware", "ValidationOptions"},
+	"url.go":  {"NewURLBuilder", "URLBuilder"},
+}
+
+// routerIdentifiers are the names router.go gets for each router.
+var routerIdentifiers = map[string][]string{
+	RouterChi:  {"Handler", "HandlerFromMux", "HandlerFromMuxWithOptions", "HandlerWithOptions"},
+	RouterEcho: {"EchoRouter", "RegisterHandlers", "RegisterHandlersWithOptions"},
+	RouterGin:  {"RegisterHandlers", "RegisterHandlersWithOptions"},
+}
+
+// HelperOptions tell the ModelNamer which of the optional helpers are generated next to the models.
+type HelperOptions struct {
+	EmbedSpec       bool
+	MockServer      bool
+	AsyncInterfaces bool
+	Router          string
+}
+
+// ModelNamer makes model names unique before flattening. Schemas losing a name to a schema met earlier
+// (components first, then properties, form, stream and callback bodies, all in sorted order) or to a helper
+// generated for this spec and these Helpers, either its identifier or its file, get an x-go-name. Operations whose
+// names collide fail whatever the strategy, since their helpers are named after them.
+type ModelNamer struct {
+	Helpers HelperOptions
+
+	doc      *spec3.T
+	strategy NamingStrategy
+	naming   Naming
+}
+
+func NewModelNamer(doc *spec3.T, strategy NamingStrategy, naming Naming) *ModelNamer {
+	return &ModelNamer{
+		doc:      doc,
+		strategy: strategy,
+		naming:   naming,
+	}
+}
+
+func (n *ModelNamer) Resolve() error {
+	if n.strategy != "" && n.strategy != NamingStrategyFail && n.strategy != NamingStrategySuffix && n.strategy != NamingStrategyPath {
+		return errors.Errorf("unknown naming strategy %s", n.strategy)
+	}
+
+	extra := n.naming.initialisms
+
+	if err := operationNameCollisions(n.doc, extra); err != nil {
+		return err
+	}
+
+	for {
+		flattener := NewFlattener(n.doc, n.naming)
+		flatSchemaRefs := flattener.Flatten()
+
+		reserved, err := reservedNames(n.doc, n.Helpers, flatSchemaRefs, extra)
+		if err != nil {
+			return err
+		}
+
+		collisions := append(flattener.Collisions(), helperCollisions(flattener.origins, reserved)...)
+		if len(collisions) == 0 {
+			return nil
+		}
+
+		if n.strategy == "" || n.strategy == NamingStrategyFail {
+			lines := make([]string, 0, len(collisions))
+			for _, collision := range collisions {
+				lines = append(lines, fmt.Sprintf("%s: %s and %s", collision.Name, collision.Origin, collision.Other))
+			}
+
+			return errors.Errorf(
+				"model name collisions, rename schemas with %s or use the suffix or path naming strategy:\n%s",
+				GoNameExtension, strings.Join(lines, "\n"),
+			)
+		}
+
+		taken := make(map[string]bool)
+		for name := range flattener.origins {
+			taken[name] = true
+		}
+
+		renamed := make(map[*spec3.Schema]bool)
+
+		for _, collision := range collisions {
+			if renamed[collision.schema] {
+				continue
+			}
+
+			name := collision.Name
+			if n.strategy == NamingStrategyPath {
+				name = pointerToModelName(collision.Other, extra)
+			}
+
+			for i := 2; taken[name] || reserved.owner(name) != ""; i++ {
+				name = fmt.Sprintf("%s%d", collision.Name, i)
+			}
+
+			if err := setSchemaGoName(collision.schema, name); err != nil {
+				return err
+			}
+
+			taken[name] = true
+			renamed[collision.schema] = true
+		}
+	}
+}
+
+type reservedModelNames struct {
+	identifiers map[string]string
+	files       map[string]string
+}
+
+// owner describes the helper a model named name would clash with, or is empty.
+func (r reservedModelNames) owner(name string) string {
+	if owner, ok := r.identifiers[name]; ok {
+		return owner
+	}
+
+	return r.files[modelToFilename(name)+".go"]
+}
+
+func reservedNames(
+	doc *spec3.T,
+	helpers HelperOptions,
+	flatSchemaRefs map[string]*spec3.SchemaRef,
+	extra initialisms,
+) (reservedModelNames, error) {
+	reserved := reservedModelNames{
+		identifiers: make(map[string]string),
+		files:       make(map[string]string),
+	}
+
+	reserve := func(names ...string) {
+		for _, name := range names {
+			reserved.identifiers[name] = "the helper " + name
+		}
+	}
+
+	reserveFile := func(filename string, names ...string) {
+		reserved.files[filename] = "the helpers in " + filename
+		reserve(names...)
+	}
+
+	operations := listOperations(doc, extra)
+
+	if len(operations) > 0 {
+		for _, filename := range []string{"server.go", "client.go", "fake_client.go", "url.go"} {
+			reserveFile(filename, helperIdentifiers[filename]...)
+		}
+
+		if helpers.Router != "" {
+			reserveFile("router.go", routerIdentifiers[helpers.Router]...)
+		}
+
+		if helpers.MockServer {
+			reserveFile("mock.go", helperIdentifiers["mock.go"]...)
+		}
+	}
+
+	if helpers.EmbedSpec {
+		for _, name := range specTemplateNames {
+			reserveFile(name+".go", helperIdentifiers[name+".go"]...)
+		}
+
+		if len(problemModelNames(doc, extra)) == 0 {
+			reserve("ProblemDetails")
+		} else {
+			reserve("ProblemFromResponse")
+		}
+	}
+
+	if len(listProblemResponses(doc, extra)) > 0 {
+		reserveFile("problem_response.go", helperIdentifiers["problem_response.go"]...)
+	}
+
+	schemes := resolveSecuritySchemes(doc, extra)
+	if len(schemes) > 0 {
+		reserveFile("auth.go", helperIdentifiers["auth.go"]...)
+	}
+
+	for _, scheme := range schemes {
+		reserve("With" + scheme.GoName)
+	}
+
+	if usesContent(doc) {
+		reserveFile("content.go", helperIdentifiers["content.go"]...)
+	}
+
+	if usesBinaryProps(flatSchemaRefs) {
+		reserveFile("form.go", helperIdentifiers["form.go"]...)
+	}
+
+	known := func(name string) bool {
+		_, ok := flatSchemaRefs[name]
+		return ok
+	}
+
+	for _, op := range operations {
+		reserve(op.Name+"Params", op.Name+"URLParams", "FakeClient"+op.Name+"Call")
+
+		requests, responses, _ := operationContentBodies(op, known, extra)
+		for _, request := range requests {
+			reserve("Encode"+op.Name+"Request"+request.Suffix, "Decode"+op.Name+"Request"+request.Suffix)
+
+			if request.IsBinary {
+				reserve("Decode" + op.Name + "Request" + request.Suffix + "Reader")
+			}
+		}
+
+		for _, response := range responses {
+			reserve("Encode"+op.Name+"Response"+response.Suffix, "Decode"+op.Name+"Response"+response.Suffix)
+
+			if response.IsBinary {
+				reserve("Encode"+op.Name+"Response"+response.Suffix+"Reader", "Decode"+op.Name+"Response"+response.Suffix+"Reader")
+			}
+		}
+
+		for _, paramRef := range operationParameters(op) {
+			reserve(op.Name + goIdentifier(paramRef.Value.Name, extra) + "Param")
+		}
+
+		for _, form := range listOperationForms(op) {
+			reserveFile("form.go", helperIdentifiers["form.go"]...)
+			reserve("Encode"+form.Name+"Body", "Decode"+form.Name+"Body")
+		}
+
+		for _, stream := range listOperationStreams(op) {
+			reserveFile("stream.go")
+			reserve(stream.Name+"StreamReader", stream.Name+"StreamWriter", "New"+stream.Name+"StreamReader", "New"+stream.Name+"StreamWriter")
+		}
+
+		if _, ok := op.Extensions[PaginationExtension]; ok {
+			reserveFile("pagination.go")
+			reserve(op.Name+"Iterator", op.Name+"All")
+		}
+	}
+
+	for _, problem := range listProblemResponses(doc, extra) {
+		reserve(problem.Operation.Name + "ProblemFromResponse")
+	}
+
+	for _, callback := range listCallbacks(doc, extra) {
+		reserveFile("callback.go", "HTTPRequestDoer")
+		reserve(callback.Name+"Receiver", "New"+callback.Name+"Handler", "Send"+callback.Name)
+
+		if !callback.IsWebhook {
+			reserve("Resolve" + callback.Name + "URL")
+		}
+	}
+
+	if !helpers.AsyncInterfaces {
+		return reserved, nil
+	}
+
+	channels, err := NewAsyncResolver(doc).Resolve()
+	if err != nil {
+		return reserved, err
+	}
+
+	for _, channel := range channels {
+		reserveFile("async.go")
+		reserve(channel.Name+"ChannelAddress", channel.Name+"Publisher", channel.Name+"Subscriber")
+	}
+
+	return reserved, nil
+}
+
+// usesBinaryProps reports whether a model gets a FormFile prop, which makes form.go generated for FormFile.
+func usesBinaryProps(flatSchemaRefs map[string]*spec3.SchemaRef) bool {
+	for _, schemaRef := range flatSchemaRefs {
+		schemas := []*spec3.SchemaRef{schemaRef}
+		schemas = append(schemas, schemaRef.Value.AllOf...)
+
+		for _, schema := range schemas {
+			if schema.Value == nil {
+				continue
+			}
+
+			for _, prop := range schema.Value.Properties {
+				if prop.Value == nil {
+					continue
+				}
+
+				if isBinary(prop.Value) || isArray(prop.Value.Type) && prop.Value.Items != nil &&
+					prop.Value.Items.Value != nil && isBinary(prop.Value.Items.Value) {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+// helperCollisions lists the models whose name or file is taken by a helper, the helper always keeps its name.
+func helperCollisions(origins map[string]modelOrigin, reserved reservedModelNames) []ModelCollision {
+	names := make([]string, 0, len(origins))
+	for name := range origins {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	collisions := make([]ModelCollision, 0)
+
+	for _, name := range names {
+		if owner := reserved.owner(name); owner != "" {
+			collisions = append(collisions, ModelCollision{
+				Name:   name,
+				Origin: owner,
+				Other:  origins[name].pointer,
+				schema: origins[name].schema,
+			})
+		}
+	}
+
+	return collisions
+}
+
+func pointerToModelName(pointer string, extra initialisms) string {
+	pointer = strings.TrimPrefix(pointer, componentSchemasPrefix)
+	pointer = strings.TrimPrefix(pointer, "#/")
+
+	tokens := strings.Split(pointer, "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+
+	return goIdentifier(strings.Join(tokens, "_"), extra)
+}
+
+func setSchemaGoName(schema *spec3.Schema, name string) error {
+	value, err := json.Marshal(name)
+	if err != nil {
+		return err
+	}
+
+	if schema.ExtensionProps.Extensions == nil {
+		schema.ExtensionProps.Extensions = make(map[string]interface{})
+	}
+
+	schema.ExtensionProps.Extensions[GoNameExtension] = json.RawMessage(value)
+
+	return nil
+}
@@ -0,0 +1,175 @@
+package generator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// commonInitialisms are kept upper case in identifiers, as golint expects them to be.
+var commonInitialisms = map[string]bool{
+	"ACL": true, "API": true, "ASCII": true, "CPU": true, "CSS": true, "DNS": true, "EOF": true, "GUID": true,
+	"HTML": true, "HTTP": true, "HTTPS": true, "ID": true, "IP": true, "JSON": true, "LHS": true, "QPS": true,
+	"RAM": true, "RHS": true, "RPC": true, "SLA": true, "SMTP": true, "SQL": true, "SSH": true, "TCP": true,
+	"TLS": true, "TTL": true, "UDP": true, "UI": true, "UID": true, "UUID": true, "URI": true, "URL": true,
+	"UTF8": true, "VM": true, "XML": true, "XMPP": true, "XSRF": true, "XSS": true,
+}
+
+// reservedFieldNames are taken by the XMLName field and the methods of generated models.
+var reservedFieldNames = map[string]bool{
+	"XMLName":  true,
+	"Validate": true,
+}
+
+// problemReservedFieldNames are also taken by the Error method of problem models.
+var problemReservedFieldNames = map[string]bool{
+	"XMLName":  true,
+	"Validate": true,
+	"Error":    true,
+}
+
+// Naming turns spec names into Go identifiers. The loader, the model namer, the resolvers and the generator take
+// the same Naming, so a name derived by one of them matches the others; the zero value knows the common
+// initialisms only.
+type Naming struct {
+	initialisms initialisms
+}
+
+// NewNaming keeps names upper case in identifiers besides the common initialisms like ID, URL and HTTP.
+func NewNaming(names []string) Naming {
+	return Naming{initialisms: newInitialisms(names)}
+}
+
+// initialisms are kept upper case in identifiers besides the common ones.
+type initialisms map[string]bool
+
+func newInitialisms(names []string) initialisms {
+	set := make(initialisms)
+
+	for _, name := range names {
+		if name = strings.TrimSpace(name); name != "" {
+			set[strings.ToUpper(name)] = true
+		}
+	}
+
+	return set
+}
+
+// goIdentifier turns a name from the spec into an exported Go identifier: words are split on any character
+// which is not a letter or digit and on case changes, common and extra initialisms are upper cased, a leading digit
+// gets an N prefix and a leading letter without case (e.g. CJK) an X prefix.
+func goIdentifier(name string, extra initialisms) string {
+	var builder strings.Builder
+
+	for _, word := range splitWords(name) {
+		if upper := strings.ToUpper(word); commonInitialisms[upper] || extra[upper] {
+			builder.WriteString(upper)
+			continue
+		}
+
+		runes := []rune(word)
+		builder.WriteRune(unicode.ToUpper(runes[0]))
+		builder.WriteString(string(runes[1:]))
+	}
+
+	identifier := builder.String()
+	if identifier == "" {
+		return "X"
+	}
+
+	first := []rune(identifier)[0]
+
+	switch {
+	case unicode.IsDigit(first):
+		return "N" + identifier
+	case !unicode.IsUpper(first):
+		return "X" + identifier
+	}
+
+	return identifier
+}
+
+func splitWords(name string) []string {
+	words := make([]string, 0)
+	runes := []rune(name)
+	start := -1
+
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if start >= 0 {
+				words = append(words, string(runes[start:i]))
+				start = -1
+			}
+
+			continue
+		}
+
+		if start >= 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+
+			// fooBar, foo2Bar and the Server of HTTPServer start new words
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextIsLower) {
+				words = append(words, string(runes[start:i]))
+				start = i
+			}
+		}
+
+		if start < 0 {
+			start = i
+		}
+	}
+
+	if start >= 0 {
+		words = append(words, string(runes[start:]))
+	}
+
+	return words
+}
+
+// resolveFieldNames makes the field names of a model unique, escapes reserved ones with an underscore suffix
+// and tags every field with its spec name, optional ones omitted when empty.
+func resolveFieldNames(props []Prop, reserved map[string]bool) {
+	indexes := make([]int, len(props))
+	for i := range props {
+		indexes[i] = i
+	}
+
+	sort.SliceStable(indexes, func(i, j int) bool {
+		return props[indexes[i]].SpecName < props[indexes[j]].SpecName
+	})
+
+	taken := make(map[string]bool)
+
+	for _, i := range indexes {
+		name := props[i].Name
+		if reserved[name] {
+			name += "_"
+		}
+
+		unique := name
+		for n := 2; taken[unique]; n++ {
+			unique = fmt.Sprintf("%s%d", name, n)
+		}
+
+		taken[unique] = true
+		props[i].Name = unique
+	}
+
+	for i := range props {
+		props[i].Tags = append(props[i].Tags, jsonTag(props[i]))
+	}
+}
+
+// jsonTag names the field after its spec name, a bare "-" would drop the field so it always gets options.
+func jsonTag(prop Prop) string {
+	switch {
+	case !prop.IsRequired:
+		return fmt.Sprintf(`json:"%s,omitempty"`, prop.SpecName)
+	case prop.SpecName == "-":
+		return `json:"-,"`
+	}
+
+	return fmt.Sprintf(`json:"%s"`, prop.SpecName)
+}
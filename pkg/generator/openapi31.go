@@ -0,0 +1,472 @@
+package generator
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/invopop/yaml"
+
+	spec3 "github.com/getkin/kin-openapi/openapi3"
+)
+
+// WebhooksExtension holds the webhooks of a 3.1 document as a *spec3.Callback keyed by webhook name once loaded, the
+// loader resolves them as a components callback of that name.
+const WebhooksExtension = "x-go-webhooks"
+
+// openAPI31Normalizer rewrites 3.1 (JSON Schema 2020-12) constructs into their 3.0 counterparts,
+// so that the document can be loaded and resolved with 3.0 semantics.
+type openAPI31Normalizer struct {
+	doc         map[string]interface{}
+	schemas     map[string]interface{}
+	hoisted     map[string]interface{}
+	renamed     map[string]string
+	defNames    []string
+	root        string
+	initialisms initialisms
+}
+
+func isOpenAPI31(doc map[string]interface{}) bool {
+	version, _ := doc["openapi"].(string)
+
+	return strings.HasPrefix(version, "3.1")
+}
+
+func readRawDocument(data []byte) (map[string]interface{}, error) {
+	jsonData, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(jsonData, &doc); err != nil {
+		return nil, err
+	}
+
+	if err := recordPropertyOrder(data, doc); err != nil {
+		return nil, err
+	}
+
+	return doc, nil
+}
+
+func normalizeOpenAPI31(doc map[string]interface{}, extra initialisms) {
+	n := &openAPI31Normalizer{
+		doc:         doc,
+		hoisted:     make(map[string]interface{}),
+		renamed:     make(map[string]string),
+		initialisms: extra,
+	}
+
+	n.normalize()
+}
+
+func (n *openAPI31Normalizer) normalize() {
+	n.doc["openapi"] = "3.0.3"
+	delete(n.doc, "jsonSchemaDialect")
+
+	if _, ok := n.doc["paths"]; !ok {
+		n.doc["paths"] = map[string]interface{}{}
+	}
+
+	components, _ := n.doc["components"].(map[string]interface{})
+	if components == nil {
+		components = make(map[string]interface{})
+		n.doc["components"] = components
+	}
+
+	n.schemas, _ = components["schemas"].(map[string]interface{})
+	if n.schemas == nil {
+		n.schemas = make(map[string]interface{})
+		components["schemas"] = n.schemas
+	}
+
+	n.walk(n.doc, "#")
+	n.flushHoisted()
+	n.rewriteRefs(n.doc)
+
+	if webhooks, ok := n.doc["webhooks"]; ok {
+		delete(n.doc, "webhooks")
+
+		callbacks, _ := components["callbacks"].(map[string]interface{})
+		if callbacks == nil {
+			callbacks = make(map[string]interface{})
+			components["callbacks"] = callbacks
+		}
+
+		callbacks[WebhooksExtension] = webhooks
+	}
+}
+
+// liftWebhooks moves the webhooks the normalizer stored as a components callback into the WebhooksExtension.
+func liftWebhooks(doc *spec3.T) {
+	callbackRef, ok := doc.Components.Callbacks[WebhooksExtension]
+	if !ok {
+		return
+	}
+
+	delete(doc.Components.Callbacks, WebhooksExtension)
+
+	if doc.Extensions == nil {
+		doc.Extensions = make(map[string]interface{})
+	}
+
+	doc.Extensions[WebhooksExtension] = callbackRef.Value
+}
+
+func (n *openAPI31Normalizer) flushHoisted() {
+	for _, name := range n.defNames {
+		n.schemas[name] = n.hoisted[name]
+	}
+
+	n.defNames = nil
+}
+
+func (n *openAPI31Normalizer) walk(node interface{}, pointer string) {
+	switch value := node.(type) {
+	case map[string]interface{}:
+		for _, key := range sortedKeys(value) {
+			childPointer := pointer + "/" + escapePointerToken(key)
+
+			switch {
+			case key == "schema":
+				value[key] = n.normalizeSchema(value[key], childPointer)
+			case key == "schemas" && pointer == "#/components":
+				schemas, _ := value[key].(map[string]interface{})
+				for _, name := range sortedKeys(schemas) {
+					schemas[name] = n.normalizeSchema(schemas[name], childPointer+"/"+escapePointerToken(name))
+				}
+			default:
+				n.walk(value[key], childPointer)
+			}
+		}
+	case []interface{}:
+		for _, item := range value {
+			n.walk(item, pointer)
+		}
+	}
+}
+
+func (n *openAPI31Normalizer) normalizeSchema(node interface{}, pointer string) interface{} {
+	schema, ok := node.(map[string]interface{})
+	if !ok {
+		return node
+	}
+
+	n.normalizeType(schema)
+	n.normalizeNullableComposition(schema, "anyOf")
+	n.normalizeNullableComposition(schema, "oneOf")
+
+	if value, ok := schema["const"]; ok {
+		schema["enum"] = []interface{}{value}
+		delete(schema, "const")
+
+		if _, ok := schema["type"]; !ok {
+			if tp := jsonValueType(value); tp != "" {
+				schema["type"] = tp
+			}
+		}
+	}
+
+	for _, bound := range []string{"Minimum", "Maximum"} {
+		if value, ok := schema["exclusive"+bound].(float64); ok {
+			schema[strings.ToLower(bound)] = value
+			schema["exclusive"+bound] = true
+		}
+	}
+
+	if examples, ok := schema["examples"].([]interface{}); ok {
+		if len(examples) > 0 {
+			if _, ok := schema["example"]; !ok {
+				schema["example"] = examples[0]
+			}
+		}
+
+		delete(schema, "examples")
+	}
+
+	if prefixItems, ok := schema["prefixItems"].([]interface{}); ok {
+		for i := range prefixItems {
+			prefixItems[i] = n.normalizeSchema(prefixItems[i], pointer+"/prefixItems/"+strconv.Itoa(i))
+		}
+
+		n.normalizePrefixItems(schema, prefixItems)
+	}
+
+	for _, key := range []string{"$defs", "definitions"} {
+		if defs, ok := schema[key].(map[string]interface{}); ok {
+			for _, name := range sortedKeys(defs) {
+				n.hoistDef(pointer, key, name, defs[name])
+			}
+
+			delete(schema, key)
+		}
+	}
+
+	for _, key := range []string{"properties", "patternProperties"} {
+		if props, ok := schema[key].(map[string]interface{}); ok {
+			for _, name := range sortedKeys(props) {
+				props[name] = n.normalizeSchema(props[name], pointer+"/"+key+"/"+escapePointerToken(name))
+			}
+		}
+	}
+
+	for _, key := range []string{"items", "additionalProperties", "not"} {
+		if _, ok := schema[key].(map[string]interface{}); ok {
+			schema[key] = n.normalizeSchema(schema[key], pointer+"/"+key)
+		}
+	}
+
+	for _, key := range []string{"allOf", "anyOf", "oneOf"} {
+		if elements, ok := schema[key].([]interface{}); ok {
+			for i := range elements {
+				elements[i] = n.normalizeSchema(elements[i], pointer+"/"+key+"/"+strconv.Itoa(i))
+			}
+		}
+	}
+
+	return schema
+}
+
+func (n *openAPI31Normalizer) normalizeType(schema map[string]interface{}) {
+	switch value := schema["type"].(type) {
+	case string:
+		if value == "null" {
+			delete(schema, "type")
+			schema["nullable"] = true
+		}
+	case []interface{}:
+		types := make([]interface{}, 0, len(value))
+
+		for _, tp := range value {
+			if tp == "null" {
+				schema["nullable"] = true
+				continue
+			}
+
+			types = append(types, tp)
+		}
+
+		delete(schema, "type")
+
+		switch len(types) {
+		case 0:
+		case 1:
+			schema["type"] = types[0]
+		default:
+			oneOf := make([]interface{}, 0, len(types))
+			for _, tp := range types {
+				oneOf = append(oneOf, map[string]interface{}{"type": tp})
+			}
+
+			schema["oneOf"] = oneOf
+		}
+	}
+}
+
+// normalizeNullableComposition turns `anyOf: [{$ref: X}, {type: "null"}]` into a nullable `allOf: [{$ref: X}]`.
+func (n *openAPI31Normalizer) normalizeNullableComposition(schema map[string]interface{}, key string) {
+	elements, ok := schema[key].([]interface{})
+	if !ok {
+		return
+	}
+
+	remaining := make([]interface{}, 0, len(elements))
+
+	for _, element := range elements {
+		if elementSchema, ok := element.(map[string]interface{}); ok && elementSchema["type"] == "null" && len(elementSchema) == 1 {
+			schema["nullable"] = true
+			continue
+		}
+
+		remaining = append(remaining, element)
+	}
+
+	if len(remaining) == len(elements) {
+		return
+	}
+
+	delete(schema, key)
+
+	switch len(remaining) {
+	case 0:
+	case 1:
+		schema["allOf"] = remaining
+	default:
+		schema[key] = remaining
+	}
+}
+
+func (n *openAPI31Normalizer) normalizePrefixItems(schema map[string]interface{}, prefixItems []interface{}) {
+	delete(schema, "prefixItems")
+
+	if _, ok := schema["type"]; !ok {
+		schema["type"] = "array"
+	}
+
+	if items, ok := schema["items"].(bool); ok && !items {
+		schema["maxItems"] = len(prefixItems)
+		delete(schema, "items")
+	}
+
+	if _, ok := schema["minItems"]; !ok {
+		schema["minItems"] = len(prefixItems)
+	}
+
+	if _, ok := schema["items"]; ok || len(prefixItems) == 0 {
+		return
+	}
+
+	homogeneous := true
+	for _, item := range prefixItems[1:] {
+		if !reflect.DeepEqual(item, prefixItems[0]) {
+			homogeneous = false
+			break
+		}
+	}
+
+	if homogeneous {
+		schema["items"] = prefixItems[0]
+	} else {
+		schema["items"] = map[string]interface{}{"oneOf": prefixItems}
+	}
+}
+
+// hoistDef moves a definition to the component schemas under its own name, else prefixed with the name of the
+// schema declaring it, else numbered.
+func (n *openAPI31Normalizer) hoistDef(pointer string, key string, name string, def interface{}) {
+	taken := func(name string) bool {
+		_, inSchemas := n.schemas[name]
+		_, inHoisted := n.hoisted[name]
+
+		return inSchemas || inHoisted
+	}
+
+	hoistedName := goIdentifier(name, n.initialisms)
+
+	if taken(hoistedName) {
+		ownedName := n.defOwnerName(pointer) + hoistedName
+
+		hoistedName = ownedName
+		for i := 2; taken(hoistedName); i++ {
+			hoistedName = fmt.Sprintf("%s%d", ownedName, i)
+		}
+	}
+
+	defPointer := pointer + "/" + key + "/" + escapePointerToken(name)
+	n.renamed[defPointer] = componentSchemasPrefix + hoistedName
+
+	n.hoisted[hoistedName] = n.normalizeSchema(def, componentSchemasPrefix+hoistedName)
+	n.defNames = append(n.defNames, hoistedName)
+}
+
+// defOwnerName names the schema declaring the definitions at pointer, skipping the keywords and indexes of
+// compositions and tuples. Definitions at the root of a standalone JSON Schema take the name of its model.
+func (n *openAPI31Normalizer) defOwnerName(pointer string) string {
+	parts := strings.Split(pointer, "/")
+
+	for i := len(parts) - 1; i > 0; i-- {
+		if _, err := strconv.Atoi(parts[i]); err == nil {
+			continue
+		}
+
+		switch parts[i] {
+		case "allOf", "anyOf", "oneOf", "prefixItems", "items", "not", "additionalProperties":
+			continue
+		}
+
+		return goIdentifier(parts[i], n.initialisms)
+	}
+
+	if n.root != "" {
+		return refToModelName(n.root, n.initialisms)
+	}
+
+	return ""
+}
+
+func (n *openAPI31Normalizer) rewriteRefs(node interface{}) {
+	switch value := node.(type) {
+	case map[string]interface{}:
+		if ref, ok := value["$ref"].(string); ok {
+			value["$ref"] = n.rewriteRef(ref)
+		}
+
+		for _, child := range value {
+			n.rewriteRefs(child)
+		}
+	case []interface{}:
+		for _, item := range value {
+			n.rewriteRefs(item)
+		}
+	}
+}
+
+func (n *openAPI31Normalizer) rewriteRef(ref string) string {
+	froms := make([]string, 0, len(n.renamed))
+	for from := range n.renamed {
+		froms = append(froms, from)
+	}
+
+	// the longest pointer wins, so that nested definitions are not rewritten through their parents
+	sort.Slice(froms, func(i, j int) bool {
+		if len(froms[i]) != len(froms[j]) {
+			return len(froms[i]) > len(froms[j])
+		}
+
+		return froms[i] < froms[j]
+	})
+
+	for rewritten := true; rewritten; {
+		rewritten = false
+
+		for _, from := range froms {
+			if ref == from || strings.HasPrefix(ref, from+"/") {
+				ref = n.renamed[from] + strings.TrimPrefix(ref, from)
+				rewritten = true
+				break
+			}
+		}
+	}
+
+	if n.root != "" && (ref == "#" || strings.HasPrefix(ref, "#/") && !strings.HasPrefix(ref, "#/components/")) {
+		ref = n.root + strings.TrimPrefix(ref, "#")
+	}
+
+	return ref
+}
+
+func jsonValueType(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case float64:
+		if v == float64(int64(v)) {
+			return "integer"
+		}
+
+		return "number"
+	}
+
+	return ""
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+func escapePointerToken(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
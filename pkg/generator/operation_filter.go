@@ -0,0 +1,237 @@
+package generator
+
+import (
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	spec3 "github.com/getkin/kin-openapi/openapi3"
+)
+
+const InternalExtension = "x-internal"
+
+type FilterOptions struct {
+	IncludeTags         []string
+	ExcludeTags         []string
+	IncludePaths        []string
+	ExcludePaths        []string
+	IncludeOperationIDs []string
+	ExcludeOperationIDs []string
+	ExcludeInternal     bool
+}
+
+func (o FilterOptions) IsEmpty() bool {
+	return len(o.IncludeTags) == 0 && len(o.ExcludeTags) == 0 &&
+		len(o.IncludePaths) == 0 && len(o.ExcludePaths) == 0 &&
+		len(o.IncludeOperationIDs) == 0 && len(o.ExcludeOperationIDs) == 0 &&
+		!o.ExcludeInternal
+}
+
+// OperationFilter removes operations not selected by the options from the document
+// and then drops the components which are not reachable from the remaining operations.
+type OperationFilter struct {
+	doc     *spec3.T
+	options FilterOptions
+	skipped []SkippedComponent
+}
+
+func NewOperationFilter(doc *spec3.T, options FilterOptions) *OperationFilter {
+	return &OperationFilter{
+		doc:     doc,
+		options: options,
+	}
+}
+
+func (f *OperationFilter) Filter() error {
+	if f.options.IsEmpty() {
+		return nil
+	}
+
+	filteredReferrers := make(map[interface{}][]string)
+
+	for _, op := range listOperations(f.doc, nil) {
+		selected, err := f.isSelected(op)
+		if err != nil {
+			return err
+		}
+
+		if !selected {
+			collectReferrers(filteredReferrers, operationLabel(op), reflect.ValueOf(op.Operation))
+			op.PathItem.SetOperation(op.Method, nil)
+		}
+	}
+
+	for pathName, pathItem := range f.doc.Paths {
+		if len(pathItem.Operations()) == 0 {
+			delete(f.doc.Paths, pathName)
+		}
+	}
+
+	f.pruneComponents(filteredReferrers)
+
+	return nil
+}
+
+// Skipped lists the component schemas dropped by the last Filter.
+func (f *OperationFilter) Skipped() []SkippedComponent {
+	return f.skipped
+}
+
+func (f *OperationFilter) isSelected(op operation) (bool, error) {
+	if f.options.ExcludeInternal && (isInternal(op.ExtensionProps) || isInternal(op.PathItem.ExtensionProps)) {
+		return false, nil
+	}
+
+	if containsAny(f.options.ExcludeTags, op.Tags) || contains(f.options.ExcludeOperationIDs, op.OperationID) {
+		return false, nil
+	}
+
+	excluded, err := matchesAnyGlob(f.options.ExcludePaths, op.Path)
+	if err != nil || excluded {
+		return false, err
+	}
+
+	if len(f.options.IncludeTags) > 0 && !containsAny(f.options.IncludeTags, op.Tags) {
+		return false, nil
+	}
+
+	if len(f.options.IncludeOperationIDs) > 0 && !contains(f.options.IncludeOperationIDs, op.OperationID) {
+		return false, nil
+	}
+
+	if len(f.options.IncludePaths) == 0 {
+		return true, nil
+	}
+
+	return matchesAnyGlob(f.options.IncludePaths, op.Path)
+}
+
+func (f *OperationFilter) pruneComponents(filteredReferrers map[interface{}][]string) {
+	reachable := make(map[interface{}]bool)
+
+	visited := make(map[uintptr]bool)
+	markReachable := func(ref *string, value interface{}) {
+		reachable[value] = true
+	}
+
+	visitRefs(reflect.ValueOf(f.doc.Paths), visited, markReachable)
+
+	if webhooks, ok := f.doc.Extensions[WebhooksExtension].(*spec3.Callback); ok {
+		visitRefs(reflect.ValueOf(webhooks), visited, markReachable)
+	}
+
+	components := &f.doc.Components
+
+	schemaNames := make([]string, 0, len(components.Schemas))
+	for name := range components.Schemas {
+		schemaNames = append(schemaNames, name)
+	}
+
+	sort.Strings(schemaNames)
+
+	f.skipped = make([]SkippedComponent, 0)
+
+	for _, name := range schemaNames {
+		value := interface{}(components.Schemas[name].Value)
+		if reachable[value] {
+			continue
+		}
+
+		delete(components.Schemas, name)
+
+		reason := "unreferenced by any kept operation"
+		if operations := filteredReferrers[value]; len(operations) > 0 {
+			reason = "only referenced from filtered " + referrerList("operation", operations)
+		}
+
+		f.skipped = append(f.skipped, SkippedComponent{
+			Name:   name,
+			Reason: reason,
+		})
+	}
+
+	for name, parameterRef := range components.Parameters {
+		if !reachable[interface{}(parameterRef.Value)] {
+			delete(components.Parameters, name)
+		}
+	}
+
+	for name, requestBodyRef := range components.RequestBodies {
+		if !reachable[interface{}(requestBodyRef.Value)] {
+			delete(components.RequestBodies, name)
+		}
+	}
+
+	for name, responseRef := range components.Responses {
+		if !reachable[interface{}(responseRef.Value)] {
+			delete(components.Responses, name)
+		}
+	}
+
+	for name, headerRef := range components.Headers {
+		if !reachable[interface{}(headerRef.Value)] {
+			delete(components.Headers, name)
+		}
+	}
+
+	for name, callbackRef := range components.Callbacks {
+		if !reachable[interface{}(callbackRef.Value)] {
+			delete(components.Callbacks, name)
+		}
+	}
+}
+
+// operationLabel names op in messages by its operationId, or by its method and path when it has none.
+func operationLabel(op operation) string {
+	if op.OperationID != "" {
+		return op.OperationID
+	}
+
+	return strings.ToUpper(op.Method) + " " + op.Path
+}
+
+func isInternal(props spec3.ExtensionProps) bool {
+	var internal bool
+
+	found, err := decodeExtension(props, InternalExtension, &internal)
+
+	return found && err == nil && internal
+}
+
+func matchesAnyGlob(patterns []string, name string) (bool, error) {
+	for _, pattern := range patterns {
+		matched, err := path.Match(pattern, name)
+		if err != nil {
+			return false, errors.Wrapf(err, "invalid path pattern %s", pattern)
+		}
+
+		if matched {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func containsAny(values []string, candidates []string) bool {
+	for _, candidate := range candidates {
+		if contains(values, candidate) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func contains(values []string, candidate string) bool {
+	for _, value := range values {
+		if value == candidate {
+			return true
+		}
+	}
+
+	return false
+}
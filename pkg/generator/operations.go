@@ -0,0 +1,413 @@
+package generator
+
+import (
+	"fmt"
+	"mime"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	spec3 "github.com/getkin/kin-openapi/openapi3"
+)
+
+const (
+	MultipartMediaType   = "multipart/form-data"
+	URLEncodedMediaType  = "application/x-www-form-urlencoded"
+	EventStreamMediaType = "text/event-stream"
+	NDJSONMediaType      = "application/x-ndjson"
+)
+
+type callbackOperation struct {
+	*spec3.Operation
+
+	Name       string
+	Expression string
+	Method     string
+	Pointer    string
+	IsWebhook  bool
+}
+
+type operation struct {
+	*spec3.Operation
+
+	Name     string
+	Method   string
+	Path     string
+	PathItem *spec3.PathItem
+	Pointer  string
+}
+
+func listOperations(doc *spec3.T, extra initialisms) []operation {
+	paths := make([]string, 0, len(doc.Paths))
+	for path := range doc.Paths {
+		paths = append(paths, path)
+	}
+
+	sort.Strings(paths)
+
+	operations := make([]operation, 0)
+
+	for _, path := range paths {
+		pathItem := doc.Paths[path]
+		pathOperations := pathItem.Operations()
+
+		methods := make([]string, 0, len(pathOperations))
+		for method := range pathOperations {
+			methods = append(methods, method)
+		}
+
+		sort.Strings(methods)
+
+		for _, method := range methods {
+			op := pathOperations[method]
+
+			operations = append(operations, operation{
+				Operation: op,
+				Name:      operationName(method, path, op, extra),
+				Method:    method,
+				Path:      path,
+				PathItem:  pathItem,
+				Pointer:   "#/paths/" + escapePointerToken(path) + "/" + strings.ToLower(method),
+			})
+		}
+	}
+
+	return operations
+}
+
+func operationName(method string, path string, op *spec3.Operation, extra initialisms) string {
+	if op.OperationID != "" {
+		return goIdentifier(op.OperationID, extra)
+	}
+
+	return goIdentifier(strings.ToLower(method)+"_"+path, extra)
+}
+
+// operationNameCollisions fails when two operations get the same Go name, e.g. operationIds getThing and get_thing,
+// listing both of them. Callbacks and webhooks are named apart from the operations, but must not collide either.
+func operationNameCollisions(doc *spec3.T, extra initialisms) error {
+	pointers := make(map[string]string)
+	lines := make([]string, 0)
+
+	for _, op := range listOperations(doc, extra) {
+		if pointer, ok := pointers[op.Name]; ok {
+			lines = append(lines, fmt.Sprintf("%s: %s and %s", op.Name, pointer, op.Pointer))
+			continue
+		}
+
+		pointers[op.Name] = op.Pointer
+	}
+
+	callbackPointers := make(map[string]string)
+
+	for _, callback := range listCallbacks(doc, extra) {
+		if pointer, ok := callbackPointers[callback.Name]; ok {
+			lines = append(lines, fmt.Sprintf("%s: %s and %s", callback.Name, pointer, callback.Pointer))
+			continue
+		}
+
+		callbackPointers[callback.Name] = callback.Pointer
+	}
+
+	if len(lines) == 0 {
+		return nil
+	}
+
+	return errors.Errorf(
+		"operation name collisions, give the operations distinct operationIds:\n%s", strings.Join(lines, "\n"),
+	)
+}
+
+// operationForm is a form request body of an operation. The multipart form is named after the operation, a URL
+// encoded form declared next to it gets a URLEncoded suffix.
+type operationForm struct {
+	Name        string
+	ContentType string
+	IsMultipart bool
+	MediaType   *spec3.MediaType
+}
+
+// listOperationForms finds the multipart and URL encoded media types of the request body of op, their parameters
+// aside.
+func listOperationForms(op operation) []operationForm {
+	if op.RequestBody == nil || op.RequestBody.Value == nil {
+		return nil
+	}
+
+	content := op.RequestBody.Value.Content
+
+	contentTypes := make([]string, 0, len(content))
+	for contentType := range content {
+		contentTypes = append(contentTypes, contentType)
+	}
+
+	sort.Strings(contentTypes)
+
+	forms := make([]operationForm, 0)
+	seen := make(map[string]bool)
+
+	for _, contentType := range contentTypes {
+		mediaTypeName, _, err := mime.ParseMediaType(contentType)
+		if err != nil || mediaTypeName != MultipartMediaType && mediaTypeName != URLEncodedMediaType || seen[mediaTypeName] {
+			continue
+		}
+
+		mediaType := content[contentType]
+		if mediaType == nil || mediaType.Schema == nil {
+			continue
+		}
+
+		seen[mediaTypeName] = true
+
+		forms = append(forms, operationForm{
+			ContentType: contentType,
+			IsMultipart: mediaTypeName == MultipartMediaType,
+			MediaType:   mediaType,
+		})
+	}
+
+	sort.SliceStable(forms, func(i, j int) bool {
+		return forms[i].IsMultipart && !forms[j].IsMultipart
+	})
+
+	for i := range forms {
+		forms[i].Name = op.Name
+		if i > 0 {
+			forms[i].Name += "URLEncoded"
+		}
+	}
+
+	return forms
+}
+
+// operationStream is a streamed success response of an operation. The event stream is named after the operation,
+// an NDJSON stream declared next to it gets an NDJSON suffix.
+type operationStream struct {
+	Name          string
+	Code          string
+	ContentType   string
+	IsEventStream bool
+	MediaType     *spec3.MediaType
+}
+
+// listOperationStreams finds the event-stream and NDJSON media types of the first success response declaring any,
+// their parameters like a charset aside.
+func listOperationStreams(op operation) []operationStream {
+	codes := make([]string, 0, len(op.Responses))
+	for code := range op.Responses {
+		codes = append(codes, code)
+	}
+
+	sort.Strings(codes)
+
+	for _, code := range codes {
+		response := op.Responses[code]
+		if !strings.HasPrefix(code, "2") || response.Value == nil {
+			continue
+		}
+
+		contentTypes := make([]string, 0, len(response.Value.Content))
+		for contentType := range response.Value.Content {
+			contentTypes = append(contentTypes, contentType)
+		}
+
+		sort.Strings(contentTypes)
+
+		streams := make([]operationStream, 0)
+		seen := make(map[string]bool)
+
+		for _, contentType := range contentTypes {
+			mediaTypeName, _, err := mime.ParseMediaType(contentType)
+			if err != nil || mediaTypeName != EventStreamMediaType && mediaTypeName != NDJSONMediaType || seen[mediaTypeName] {
+				continue
+			}
+
+			mediaType := response.Value.Content[contentType]
+			if mediaType == nil || mediaType.Schema == nil {
+				continue
+			}
+
+			seen[mediaTypeName] = true
+
+			streams = append(streams, operationStream{
+				Code:          code,
+				ContentType:   contentType,
+				IsEventStream: mediaTypeName == EventStreamMediaType,
+				MediaType:     mediaType,
+			})
+		}
+
+		if len(streams) == 0 {
+			continue
+		}
+
+		sort.SliceStable(streams, func(i, j int) bool {
+			return streams[i].IsEventStream && !streams[j].IsEventStream
+		})
+
+		for i := range streams {
+			streams[i].Name = op.Name
+			if i > 0 {
+				streams[i].Name += "NDJSON"
+			}
+		}
+
+		return streams
+	}
+
+	return nil
+}
+
+func listCallbacks(doc *spec3.T, extra initialisms) []callbackOperation {
+	callbacks := make([]callbackOperation, 0)
+
+	for _, op := range listOperations(doc, extra) {
+		names := make([]string, 0, len(op.Callbacks))
+		for name := range op.Callbacks {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+
+		for _, name := range names {
+			callbackRef := op.Callbacks[name]
+			if callbackRef.Value == nil {
+				continue
+			}
+
+			expressions := make([]string, 0, len(*callbackRef.Value))
+			for expression := range *callbackRef.Value {
+				expressions = append(expressions, expression)
+			}
+
+			sort.Strings(expressions)
+
+			for _, expression := range expressions {
+				callbackOperations := (*callbackRef.Value)[expression].Operations()
+
+				methods := make([]string, 0, len(callbackOperations))
+				for method := range callbackOperations {
+					methods = append(methods, method)
+				}
+
+				sort.Strings(methods)
+
+				for _, method := range methods {
+					callbackOp := callbackOperations[method]
+
+					callbackName := op.Name + goIdentifier(name, extra)
+					if callbackOp.OperationID != "" {
+						callbackName = goIdentifier(callbackOp.OperationID, extra)
+					} else if len(expressions) > 1 || len(methods) > 1 {
+						callbackName += operationName(method, expression, callbackOp, extra)
+					}
+
+					callbacks = append(callbacks, callbackOperation{
+						Operation:  callbackOp,
+						Name:       callbackName,
+						Expression: expression,
+						Method:     method,
+						Pointer: fmt.Sprintf(
+							"%s/callbacks/%s/%s/%s",
+							op.Pointer, escapePointerToken(name), escapePointerToken(expression), strings.ToLower(method),
+						),
+					})
+				}
+			}
+		}
+	}
+
+	return append(callbacks, listWebhooks(doc, extra)...)
+}
+
+// listWebhooks lists the operations of the 3.1 webhooks as callbacks without a URL expression, named after their
+// operationId or the webhook.
+func listWebhooks(doc *spec3.T, extra initialisms) []callbackOperation {
+	webhooks, _ := doc.Extensions[WebhooksExtension].(*spec3.Callback)
+	if webhooks == nil {
+		return nil
+	}
+
+	names := make([]string, 0, len(*webhooks))
+	for name := range *webhooks {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	callbacks := make([]callbackOperation, 0)
+
+	for _, name := range names {
+		webhookOperations := (*webhooks)[name].Operations()
+
+		methods := make([]string, 0, len(webhookOperations))
+		for method := range webhookOperations {
+			methods = append(methods, method)
+		}
+
+		sort.Strings(methods)
+
+		for _, method := range methods {
+			webhookOp := webhookOperations[method]
+
+			webhookName := goIdentifier(name, extra)
+			if webhookOp.OperationID != "" {
+				webhookName = goIdentifier(webhookOp.OperationID, extra)
+			} else if len(methods) > 1 {
+				webhookName += goIdentifier(strings.ToLower(method), extra)
+			}
+
+			callbacks = append(callbacks, callbackOperation{
+				Operation: webhookOp,
+				Name:      webhookName,
+				Method:    method,
+				Pointer:   "#/webhooks/" + escapePointerToken(name) + "/" + strings.ToLower(method),
+				IsWebhook: true,
+			})
+		}
+	}
+
+	return callbacks
+}
+
+func getJSONMediaType(op *spec3.Operation) *spec3.MediaType {
+	if op.RequestBody == nil || op.RequestBody.Value == nil {
+		return nil
+	}
+
+	content := op.RequestBody.Value.Content
+
+	mediaTypes := make([]string, 0, len(content))
+	for mediaType := range content {
+		mediaTypes = append(mediaTypes, mediaType)
+	}
+
+	sort.Strings(mediaTypes)
+
+	for _, mediaType := range mediaTypes {
+		if (mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")) && content[mediaType].Schema != nil {
+			return content[mediaType]
+		}
+	}
+
+	return nil
+}
+
+func operationParameters(op operation) spec3.Parameters {
+	params := make(spec3.Parameters, 0, len(op.PathItem.Parameters)+len(op.Parameters))
+
+	for _, paramRef := range op.PathItem.Parameters {
+		if paramRef.Value != nil && op.Parameters.GetByInAndName(paramRef.Value.In, paramRef.Value.Name) == nil {
+			params = append(params, paramRef)
+		}
+	}
+
+	for _, paramRef := range op.Parameters {
+		if paramRef.Value != nil {
+			params = append(params, paramRef)
+		}
+	}
+
+	return params
+}
@@ -0,0 +1,206 @@
+package generator
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+
+	spec3 "github.com/getkin/kin-openapi/openapi3"
+)
+
+const PaginationExtension = "x-pagination"
+
+type paginationExtension struct {
+	CursorParam     string `json:"cursorParam"`
+	NextCursorField string `json:"nextCursorField"`
+	OffsetParam     string `json:"offsetParam"`
+	ItemsField      string `json:"itemsField"`
+}
+
+type PaginationModel struct {
+	Name             string
+	PageType         string
+	ItemType         string
+	ItemsField       string
+	CursorParam      string
+	CursorParamIsPtr bool
+	NextCursorField  string
+	NextCursorIsPtr  bool
+	OffsetParam      string
+	OffsetParamIsPtr bool
+	PathParams       []*ServerParam
+}
+
+type PaginationResolver struct {
+	doc    *spec3.T
+	naming Naming
+	models map[string]*Model
+}
+
+func NewPaginationResolver(doc *spec3.T, models map[string]*Model, naming Naming) *PaginationResolver {
+	return &PaginationResolver{
+		doc:    doc,
+		models: models,
+		naming: naming,
+	}
+}
+
+func (r *PaginationResolver) Resolve() ([]*PaginationModel, error) {
+	paginations := make([]*PaginationModel, 0)
+	extra := r.naming.initialisms
+
+	for _, op := range listOperations(r.doc, extra) {
+		var extension paginationExtension
+
+		found, err := decodeExtension(op.ExtensionProps, PaginationExtension, &extension)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid %s of operation %s", PaginationExtension, op.Name)
+		}
+
+		if !found {
+			continue
+		}
+
+		pagination, err := r.buildPagination(op, extension, extra)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid %s of operation %s", PaginationExtension, op.Name)
+		}
+
+		paginations = append(paginations, pagination)
+	}
+
+	return paginations, nil
+}
+
+func (r *PaginationResolver) buildPagination(op operation, extension paginationExtension, extra initialisms) (*PaginationModel, error) {
+	if extension.ItemsField == "" {
+		return nil, errors.New("itemsField must be provided")
+	}
+
+	if (extension.CursorParam == "") == (extension.OffsetParam == "") {
+		return nil, errors.New("exactly one of cursorParam or offsetParam must be provided")
+	}
+
+	if extension.CursorParam != "" && extension.NextCursorField == "" {
+		return nil, errors.New("nextCursorField must be provided with cursorParam")
+	}
+
+	if op.RequestBody != nil {
+		return nil, errors.New("operation with a request body can't be paginated")
+	}
+
+	pageModel, err := r.pageModel(op, extra)
+	if err != nil {
+		return nil, err
+	}
+
+	pagination := &PaginationModel{
+		Name:       op.Name,
+		PageType:   pageModel.Name,
+		PathParams: paginationPathParams(op, extra),
+	}
+
+	itemsProp := findPropBySpecName(pageModel, extension.ItemsField)
+	if itemsProp == nil || !strings.HasPrefix(itemsProp.GoType.Name, "[]") {
+		return nil, errors.Errorf("items field %q is not an array of %s", extension.ItemsField, pageModel.Name)
+	}
+
+	pagination.ItemsField = itemsProp.Name
+	pagination.ItemType = strings.TrimPrefix(itemsProp.GoType.Name, "[]")
+
+	if extension.CursorParam != "" {
+		param, err := paginationParam(op, extension.CursorParam, extra)
+		if err != nil {
+			return nil, err
+		}
+
+		if strings.TrimPrefix(param.GoType, "*") != "string" {
+			return nil, errors.Errorf("cursor param %q must be a string", extension.CursorParam)
+		}
+
+		cursorProp := findPropBySpecName(pageModel, extension.NextCursorField)
+		if cursorProp == nil || strings.TrimPrefix(cursorProp.GoType.Name, "*") != "string" {
+			return nil, errors.Errorf("next cursor field %q is not a string of %s", extension.NextCursorField, pageModel.Name)
+		}
+
+		pagination.CursorParam = param.GoName
+		pagination.CursorParamIsPtr = strings.HasPrefix(param.GoType, "*")
+		pagination.NextCursorField = cursorProp.Name
+		pagination.NextCursorIsPtr = cursorProp.GoType.IsPtr
+	} else {
+		param, err := paginationParam(op, extension.OffsetParam, extra)
+		if err != nil {
+			return nil, err
+		}
+
+		if strings.TrimPrefix(param.GoType, "*") != "int" {
+			return nil, errors.Errorf("offset param %q must be an integer", extension.OffsetParam)
+		}
+
+		pagination.OffsetParam = param.GoName
+		pagination.OffsetParamIsPtr = strings.HasPrefix(param.GoType, "*")
+	}
+
+	return pagination, nil
+}
+
+func (r *PaginationResolver) pageModel(op operation, extra initialisms) (*Model, error) {
+	responseRef := op.Responses.Get(200)
+	if responseRef == nil || responseRef.Value == nil {
+		return nil, errors.New("operation has no 200 response")
+	}
+
+	mediaType := responseRef.Value.Content.Get("application/json")
+	if mediaType == nil || mediaType.Schema == nil || mediaType.Schema.Ref == "" {
+		return nil, errors.New("200 response must reference a schema component as application/json")
+	}
+
+	model, ok := r.models[customSchemaModelName("", "", mediaType.Schema, extra)]
+	if !ok {
+		return nil, errors.Errorf("there is no model for %s", mediaType.Schema.Ref)
+	}
+
+	return model, nil
+}
+
+// paginationPathParams lists the path params <Operation>All passes on to the client method.
+func paginationPathParams(op operation, extra initialisms) []*ServerParam {
+	params := make([]*ServerParam, 0)
+	args := map[string]bool{"ctx": true, "client": true, "params": true}
+
+	for _, paramRef := range operationParameters(op) {
+		if paramRef.Value.In != spec3.ParameterInPath {
+			continue
+		}
+
+		urlParam := buildURLParam(op, paramRef.Value, extra)
+
+		params = append(params, &ServerParam{
+			URLParam: urlParam,
+			ArgName:  argName(urlParam.GoName, args),
+			Required: true,
+		})
+	}
+
+	return params
+}
+
+func paginationParam(op operation, name string, extra initialisms) (*URLParam, error) {
+	for _, paramRef := range operationParameters(op) {
+		if paramRef.Value.In == spec3.ParameterInQuery && paramRef.Value.Name == name {
+			return buildURLParam(op, paramRef.Value, extra), nil
+		}
+	}
+
+	return nil, errors.Errorf("there is no query param %q", name)
+}
+
+func findPropBySpecName(model *Model, specName string) *Prop {
+	for i := range model.Props {
+		if model.Props[i].SpecName == specName {
+			return &model.Props[i]
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,183 @@
+package generator
+
+import (
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+
+	spec3 "github.com/getkin/kin-openapi/openapi3"
+)
+
+const ProblemMediaType = "application/problem+json"
+
+// problemResponse is an application/problem+json response of an operation, inline schemas get a model named after
+// the operation and the status code.
+type problemResponse struct {
+	Operation operation
+	Code      string
+	Model     string
+	Pointer   string
+	Schema    *spec3.SchemaRef
+}
+
+func listProblemResponses(doc *spec3.T, extra initialisms) []problemResponse {
+	problems := make([]problemResponse, 0)
+
+	for _, op := range listOperations(doc, extra) {
+		codes := make([]string, 0, len(op.Responses))
+		for code := range op.Responses {
+			codes = append(codes, code)
+		}
+
+		sort.Strings(codes)
+
+		for _, code := range codes {
+			responseRef := op.Responses[code]
+			if responseRef.Value == nil {
+				continue
+			}
+
+			contentTypes := make([]string, 0, len(responseRef.Value.Content))
+			for contentType := range responseRef.Value.Content {
+				contentTypes = append(contentTypes, contentType)
+			}
+
+			sort.Strings(contentTypes)
+
+			for _, contentType := range contentTypes {
+				if parsed, _, err := mime.ParseMediaType(contentType); err != nil || parsed != ProblemMediaType {
+					continue
+				}
+
+				schema := responseRef.Value.Content[contentType].Schema
+				if schema == nil || schema.Value == nil || isArray(schema.Value.Type) || getCustomTypeSchemaRef(schema) == nil {
+					continue
+				}
+
+				problems = append(problems, problemResponse{
+					Operation: op,
+					Code:      code,
+					Model:     customSchemaModelName(op.Name, code+"Problem", schema, extra),
+					Pointer:   op.Pointer + "/responses/" + escapePointerToken(code) + "/content/" + escapePointerToken(contentType) + "/schema",
+					Schema:    schema,
+				})
+
+				break
+			}
+		}
+	}
+
+	return problems
+}
+
+func problemModelNames(doc *spec3.T, extra initialisms) []string {
+	unique := make(map[string]bool)
+
+	for _, problem := range listProblemResponses(doc, extra) {
+		unique[problem.Model] = true
+	}
+
+	names := make([]string, 0, len(unique))
+	for name := range unique {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// problemSchemas lists the schemas of the problem responses, their models keep the Error field name free for the
+// Error method and implement error.
+func problemSchemas(doc *spec3.T, extra initialisms) map[*spec3.Schema]bool {
+	schemas := make(map[*spec3.Schema]bool)
+
+	for _, problem := range listProblemResponses(doc, extra) {
+		schemas[getCustomTypeSchemaRef(problem.Schema).Value] = true
+	}
+
+	return schemas
+}
+
+func primaryProblemModelName(doc *spec3.T, extra initialisms) string {
+	names := problemModelNames(doc, extra)
+	if len(names) == 0 {
+		return ""
+	}
+
+	return names[0]
+}
+
+type ProblemStatus struct {
+	StatusCode  int
+	StatusClass int
+	Model       string
+}
+
+// ProblemOperation lists the problem models of an operation, exact status codes come before ranges like 4XX and
+// the default response. Method and Path key the operation for the route lookup of the embedded spec.
+type ProblemOperation struct {
+	Name         string
+	Method       string
+	Path         string
+	Statuses     []ProblemStatus
+	DefaultModel string
+}
+
+type ProblemResolver struct {
+	doc    *spec3.T
+	naming Naming
+	models map[string]*Model
+}
+
+func NewProblemResolver(doc *spec3.T, models map[string]*Model, naming Naming) *ProblemResolver {
+	return &ProblemResolver{
+		doc:    doc,
+		models: models,
+		naming: naming,
+	}
+}
+
+func (r *ProblemResolver) Resolve() []*ProblemOperation {
+	extra := r.naming.initialisms
+
+	operations := make([]*ProblemOperation, 0)
+	byName := make(map[string]*ProblemOperation)
+
+	for _, problem := range listProblemResponses(r.doc, extra) {
+		if _, ok := r.models[problem.Model]; !ok {
+			continue
+		}
+
+		operation, ok := byName[problem.Operation.Name]
+		if !ok {
+			operation = &ProblemOperation{
+				Name:   problem.Operation.Name,
+				Method: strings.ToUpper(problem.Operation.Method),
+				Path:   problem.Operation.Path,
+			}
+
+			byName[operation.Name] = operation
+			operations = append(operations, operation)
+		}
+
+		code := strings.ToUpper(problem.Code)
+
+		switch {
+		case code == "DEFAULT":
+			operation.DefaultModel = problem.Model
+		case len(code) == 3 && strings.HasSuffix(code, "XX"):
+			operation.Statuses = append(operation.Statuses, ProblemStatus{StatusClass: int(code[0] - '0'), Model: problem.Model})
+		default:
+			statusCode, err := strconv.Atoi(code)
+			if err != nil {
+				continue
+			}
+
+			operation.Statuses = append(operation.Statuses, ProblemStatus{StatusCode: statusCode, Model: problem.Model})
+		}
+	}
+
+	return operations
+}
@@ -0,0 +1,146 @@
+package generator
+
+import (
+	"sort"
+
+	yamlv3 "gopkg.in/yaml.v3"
+
+	spec3 "github.com/getkin/kin-openapi/openapi3"
+)
+
+const (
+	OrderExtension = "x-order"
+
+	// propertyOrderExtension keeps the declaration order of properties, which is lost once they are loaded into maps
+	propertyOrderExtension = "x-go-property-order"
+)
+
+type PropertyOrder string
+
+const (
+	PropertyOrderDeclared     PropertyOrder = "declared"
+	PropertyOrderAlphabetical PropertyOrder = "alphabetical"
+)
+
+// dataKeywords hold plain values rather than schemas, so their properties keys aren't keywords
+var dataKeywords = map[string]bool{
+	"example":  true,
+	"examples": true,
+	"default":  true,
+	"enum":     true,
+	"const":    true,
+}
+
+// recordPropertyOrder stores the order of the properties keys of every schema in the document into the schema itself.
+func recordPropertyOrder(data []byte, doc map[string]interface{}) error {
+	var root yamlv3.Node
+	if err := yamlv3.Unmarshal(data, &root); err != nil {
+		return err
+	}
+
+	if len(root.Content) > 0 {
+		recordNodePropertyOrder(root.Content[0], doc, false)
+	}
+
+	return nil
+}
+
+func recordNodePropertyOrder(node *yamlv3.Node, value interface{}, isPropertiesMap bool) {
+	if node.Kind == yamlv3.AliasNode {
+		node = node.Alias
+	}
+
+	switch node.Kind {
+	case yamlv3.MappingNode:
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return
+		}
+
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, child := node.Content[i].Value, node.Content[i+1]
+
+			if dataKeywords[key] && !isPropertiesMap {
+				continue
+			}
+
+			isProperties := key == "properties" && !isPropertiesMap && child.Kind == yamlv3.MappingNode
+			if isProperties {
+				order := make([]interface{}, 0, len(child.Content)/2)
+				for j := 0; j+1 < len(child.Content); j += 2 {
+					order = append(order, child.Content[j].Value)
+				}
+
+				object[propertyOrderExtension] = order
+			}
+
+			recordNodePropertyOrder(child, object[key], isProperties)
+		}
+	case yamlv3.SequenceNode:
+		items, ok := value.([]interface{})
+		if !ok {
+			return
+		}
+
+		for i := 0; i < len(node.Content) && i < len(items); i++ {
+			recordNodePropertyOrder(node.Content[i], items[i], false)
+		}
+	}
+}
+
+// orderedPropertyNames lists the properties of the schema in declaration order, the ones without a known position
+// follow alphabetically.
+func orderedPropertyNames(schema *spec3.Schema) []string {
+	var declared []string
+
+	if _, err := decodeExtension(schema.ExtensionProps, propertyOrderExtension, &declared); err != nil {
+		declared = nil
+	}
+
+	names := make([]string, 0, len(schema.Properties))
+	seen := make(map[string]bool, len(schema.Properties))
+
+	for _, name := range declared {
+		if _, ok := schema.Properties[name]; ok && !seen[name] {
+			names = append(names, name)
+			seen[name] = true
+		}
+	}
+
+	rest := make([]string, 0)
+	for name := range schema.Properties {
+		if !seen[name] {
+			rest = append(rest, name)
+		}
+	}
+
+	sort.Strings(rest)
+
+	return append(names, rest...)
+}
+
+// sortPropsByOrder moves the props with an x-order ahead of the others, ascending by its value.
+func sortPropsByOrder(props []Prop) {
+	sort.SliceStable(props, func(i, j int) bool {
+		left, right := props[i].order, props[j].order
+
+		switch {
+		case left == nil:
+			return false
+		case right == nil:
+			return true
+		}
+
+		return *left < *right
+	})
+}
+
+func schemaOrder(schema *spec3.Schema) *float64 {
+	var order float64
+
+	if found, err := decodeExtension(schema.ExtensionProps, OrderExtension, &order); !found || err != nil {
+		return nil
+	}
+
+	return &order
+}
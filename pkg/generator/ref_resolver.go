@@ -0,0 +1,373 @@
+package generator
+
+import (
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var componentSchemaFragments = []string{"/components/schemas/", "/definitions/", "/$defs/"}
+
+// refResolver turns refs to other files into local refs: every referenced external schema becomes a component schema
+// named after its canonical URI (absolute file path plus fragment), other external objects are inlined.
+type refResolver struct {
+	root        string
+	names       map[string]string
+	schemas     map[string]interface{}
+	origins     map[string]string
+	assigned    map[string]string
+	documents   map[string]map[string]interface{}
+	inlining    map[string]bool
+	initialisms initialisms
+}
+
+// resolveExternalRefs rewrites the document at path in place. Names maps a canonical URI,
+// or a path relative to the working directory optionally followed by a fragment, to the name of its model.
+func resolveExternalRefs(doc map[string]interface{}, path string, names map[string]string, extra initialisms) error {
+	root, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+
+	r := &refResolver{
+		root:        root,
+		names:       make(map[string]string),
+		origins:     make(map[string]string),
+		assigned:    make(map[string]string),
+		documents:   map[string]map[string]interface{}{root: doc},
+		inlining:    make(map[string]bool),
+		initialisms: extra,
+	}
+
+	for uri, name := range names {
+		canonical, err := canonicalRef("", uri)
+		if err != nil {
+			return errors.Wrapf(err, "invalid ref name %s", uri)
+		}
+
+		r.names[canonical] = name
+	}
+
+	components, _ := doc["components"].(map[string]interface{})
+	if components == nil {
+		components = make(map[string]interface{})
+		doc["components"] = components
+	}
+
+	r.schemas, _ = components["schemas"].(map[string]interface{})
+	if r.schemas == nil {
+		r.schemas = make(map[string]interface{})
+		components["schemas"] = r.schemas
+	}
+
+	for _, name := range sortedKeys(r.schemas) {
+		uri := root + "#" + componentSchemasPrefix[1:] + escapePointerToken(name)
+
+		// a component aliasing another file names the schema of that file
+		if schema, ok := r.schemas[name].(map[string]interface{}); ok {
+			if ref, ok := schema["$ref"].(string); ok {
+				if target, err := canonicalRef(root, ref); err == nil && target != "" && !strings.HasPrefix(target, root+"#") {
+					uri = target
+				}
+			}
+		}
+
+		r.origins[name] = uri
+		r.assigned[uri] = name
+	}
+
+	for _, name := range sortedKeys(r.schemas) {
+		uri := r.origins[name]
+		if strings.HasPrefix(uri, root+"#") {
+			continue
+		}
+
+		target, file, err := r.lookup(uri)
+		if err != nil {
+			return err
+		}
+
+		if r.schemas[name], err = r.resolve(copyRawValue(target), file, true); err != nil {
+			return err
+		}
+	}
+
+	_, err = r.resolve(doc, root, false)
+
+	return err
+}
+
+func (r *refResolver) resolve(node interface{}, base string, isSchema bool) (interface{}, error) {
+	switch value := node.(type) {
+	case map[string]interface{}:
+		if ref, ok := value["$ref"].(string); ok {
+			return r.resolveRef(value, ref, base, isSchema)
+		}
+
+		if !isSchema {
+			for _, key := range sortedKeys(value) {
+				var err error
+
+				// the values of components.schemas are schemas themselves
+				if schemas, ok := value[key].(map[string]interface{}); ok && key == "schemas" {
+					for _, name := range sortedKeys(schemas) {
+						if schemas[name], err = r.resolve(schemas[name], base, true); err != nil {
+							return nil, err
+						}
+					}
+
+					continue
+				}
+
+				if value[key], err = r.resolve(value[key], base, key == "schema"); err != nil {
+					return nil, err
+				}
+			}
+
+			return value, nil
+		}
+
+		return value, r.resolveSchemaKeywords(value, base)
+	case []interface{}:
+		for i := range value {
+			var err error
+			if value[i], err = r.resolve(value[i], base, isSchema); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return node, nil
+}
+
+func (r *refResolver) resolveSchemaKeywords(schema map[string]interface{}, base string) error {
+	var err error
+
+	for _, key := range []string{"properties", "patternProperties", "$defs", "definitions"} {
+		children, _ := schema[key].(map[string]interface{})
+		for _, name := range sortedKeys(children) {
+			if children[name], err = r.resolve(children[name], base, true); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, key := range []string{"items", "additionalProperties", "not", "allOf", "anyOf", "oneOf", "prefixItems"} {
+		if child, ok := schema[key]; ok {
+			if schema[key], err = r.resolve(child, base, true); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (r *refResolver) resolveRef(node map[string]interface{}, ref string, base string, isSchema bool) (interface{}, error) {
+	uri, err := canonicalRef(base, ref)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid ref %s", ref)
+	}
+
+	// remote refs are left to the loader
+	if uri == "" {
+		return node, nil
+	}
+
+	if strings.HasPrefix(uri, r.root+"#") {
+		_, fragment := splitRef(uri)
+		node["$ref"] = "#" + fragment
+
+		return node, nil
+	}
+
+	if !isSchema {
+		if r.inlining[uri] {
+			return nil, errors.Errorf("circular ref %s", uri)
+		}
+
+		r.inlining[uri] = true
+		defer delete(r.inlining, uri)
+
+		target, file, err := r.lookup(uri)
+		if err != nil {
+			return nil, err
+		}
+
+		return r.resolve(copyRawValue(target), file, false)
+	}
+
+	name, err := r.schemaName(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{"$ref": componentSchemasPrefix + escapePointerToken(name)}, nil
+}
+
+func (r *refResolver) schemaName(uri string) (string, error) {
+	if name, ok := r.assigned[uri]; ok {
+		return name, nil
+	}
+
+	target, file, err := r.lookup(uri)
+	if err != nil {
+		return "", err
+	}
+
+	name := r.names[uri]
+	if name == "" {
+		name = refTargetName(uri, target, r.initialisms)
+	}
+
+	if origin, taken := r.origins[name]; taken {
+		return "", errors.Errorf(
+			"model name %s of %s collides with %s, assign distinct names with the ref names option", name, uri, origin,
+		)
+	}
+
+	r.origins[name] = uri
+	r.assigned[uri] = name
+
+	// the placeholder keeps recursive refs from walking the same schema twice
+	r.schemas[name] = nil
+
+	schema, err := r.resolve(copyRawValue(target), file, true)
+	if err != nil {
+		return "", err
+	}
+
+	r.schemas[name] = schema
+
+	return name, nil
+}
+
+func (r *refResolver) lookup(uri string) (interface{}, string, error) {
+	file, fragment := splitRef(uri)
+
+	doc, ok := r.documents[file]
+	if !ok {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, "", err
+		}
+
+		if doc, err = readRawDocument(data); err != nil {
+			return nil, "", errors.Wrapf(err, "failed while reading %s", file)
+		}
+
+		r.documents[file] = doc
+	}
+
+	var target interface{} = doc
+
+	for _, token := range strings.Split(strings.TrimPrefix(fragment, "/"), "/") {
+		if token == "" {
+			continue
+		}
+
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+
+		object, ok := target.(map[string]interface{})
+		if !ok {
+			return nil, "", errors.Errorf("ref %s points to nothing", uri)
+		}
+
+		if target, ok = object[token]; !ok {
+			return nil, "", errors.Errorf("ref %s points to nothing", uri)
+		}
+	}
+
+	return target, file, nil
+}
+
+// canonicalRef resolves ref against the base file into an absolute path followed by its fragment,
+// remote refs are canonicalized into an empty string.
+func canonicalRef(base string, ref string) (string, error) {
+	parsed, err := url.Parse(ref)
+	if err != nil {
+		return "", err
+	}
+
+	if parsed.Scheme != "" && parsed.Scheme != "file" {
+		return "", nil
+	}
+
+	file := parsed.Path
+	switch {
+	case file == "":
+		file = base
+	case !filepath.IsAbs(file) && base != "":
+		file = filepath.Join(filepath.Dir(base), filepath.FromSlash(file))
+	default:
+		if file, err = filepath.Abs(filepath.FromSlash(file)); err != nil {
+			return "", err
+		}
+	}
+
+	// cleaning also drops the trailing slash of refs like ./openapi.yaml/#/components/schemas/UserProfile
+	file = filepath.Clean(file)
+
+	fragment := strings.TrimSuffix(parsed.Fragment, "/")
+	if fragment == "" {
+		return file, nil
+	}
+
+	return file + "#" + fragment, nil
+}
+
+func splitRef(uri string) (string, string) {
+	if i := strings.Index(uri, "#"); i >= 0 {
+		return uri[:i], uri[i+1:]
+	}
+
+	return uri, ""
+}
+
+// refTargetName names a schema after its component key, its title or, for whole files, the file name.
+func refTargetName(uri string, target interface{}, extra initialisms) string {
+	file, fragment := splitRef(uri)
+
+	for _, prefix := range componentSchemaFragments {
+		if strings.HasPrefix(fragment, prefix) && !strings.Contains(strings.TrimPrefix(fragment, prefix), "/") {
+			return strings.ReplaceAll(strings.ReplaceAll(strings.TrimPrefix(fragment, prefix), "~1", "/"), "~0", "~")
+		}
+	}
+
+	if schema, ok := target.(map[string]interface{}); ok {
+		if title, ok := schema["title"].(string); ok && title != "" {
+			return goIdentifier(title, extra)
+		}
+	}
+
+	if fragment != "" {
+		return goIdentifier(path.Base(fragment), extra)
+	}
+
+	return goIdentifier(strings.TrimSuffix(getBaseFilename(file), ".schema"), extra)
+}
+
+func copyRawValue(node interface{}) interface{} {
+	switch value := node.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(value))
+		for key, child := range value {
+			copied[key] = copyRawValue(child)
+		}
+
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(value))
+		for i, child := range value {
+			copied[i] = copyRawValue(child)
+		}
+
+		return copied
+	}
+
+	return node
+}
@@ -0,0 +1,202 @@
+package generator
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	spec3 "github.com/getkin/kin-openapi/openapi3"
+)
+
+type SkippedComponent struct {
+	Name   string
+	Reason string
+}
+
+// SchemaPruner drops every component schema which is not reachable from the root schemas.
+// Schemas of form, stream, problem and callback bodies are kept as well, since models are generated for them.
+type SchemaPruner struct {
+	doc   *spec3.T
+	roots []string
+}
+
+func NewSchemaPruner(doc *spec3.T, roots []string) *SchemaPruner {
+	return &SchemaPruner{
+		doc:   doc,
+		roots: roots,
+	}
+}
+
+func (p *SchemaPruner) Prune() ([]SkippedComponent, error) {
+	if len(p.roots) == 0 {
+		return nil, nil
+	}
+
+	rootNames, err := p.matchRoots()
+	if err != nil {
+		return nil, err
+	}
+
+	reachable := make(map[interface{}]bool)
+	visited := make(map[uintptr]bool)
+
+	visit := func(ref *string, value interface{}) {
+		reachable[value] = true
+	}
+
+	for _, name := range rootNames {
+		schemaRef := p.doc.Components.Schemas[name]
+		reachable[interface{}(schemaRef.Value)] = true
+		visitRefs(reflect.ValueOf(schemaRef.Value), visited, visit)
+	}
+
+	for _, schemaRef := range p.operationSchemaRefs() {
+		reachable[interface{}(schemaRef.Value)] = true
+		visitRefs(reflect.ValueOf(schemaRef.Value), visited, visit)
+	}
+
+	names := make([]string, 0, len(p.doc.Components.Schemas))
+	for name := range p.doc.Components.Schemas {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	operationReferrers := make(map[interface{}][]string)
+	for _, op := range listOperations(p.doc, nil) {
+		collectReferrers(operationReferrers, operationLabel(op), reflect.ValueOf(op.Operation))
+	}
+
+	componentReferrers := make(map[interface{}][]string)
+	for _, name := range names {
+		collectReferrers(componentReferrers, name, reflect.ValueOf(p.doc.Components.Schemas[name].Value))
+	}
+
+	skipped := make([]SkippedComponent, 0)
+	roots := strings.Join(rootNames, ", ")
+
+	for _, name := range names {
+		value := interface{}(p.doc.Components.Schemas[name].Value)
+		if reachable[value] {
+			continue
+		}
+
+		delete(p.doc.Components.Schemas, name)
+
+		reason := fmt.Sprintf("unreferenced by roots %s and any operation", roots)
+
+		if operations := operationReferrers[value]; len(operations) > 0 {
+			reason = fmt.Sprintf("only referenced from %s, not from roots %s", referrerList("operation", operations), roots)
+		} else if components := withoutName(componentReferrers[value], name); len(components) > 0 {
+			reason = fmt.Sprintf("only referenced from skipped %s", referrerList("component", components))
+		}
+
+		skipped = append(skipped, SkippedComponent{
+			Name:   name,
+			Reason: reason,
+		})
+	}
+
+	return skipped, nil
+}
+
+// collectReferrers adds name to the referrers of every value reachable through refs from v.
+func collectReferrers(referrers map[interface{}][]string, name string, v reflect.Value) {
+	visitRefs(v, make(map[uintptr]bool), func(ref *string, value interface{}) {
+		names := referrers[value]
+		if len(names) == 0 || names[len(names)-1] != name {
+			referrers[value] = append(names, name)
+		}
+	})
+}
+
+func referrerList(kind string, names []string) string {
+	sorted := append([]string(nil), names...)
+	sort.Strings(sorted)
+
+	if len(sorted) > 1 {
+		kind += "s"
+	}
+
+	return kind + " " + strings.Join(sorted, ", ")
+}
+
+func withoutName(names []string, name string) []string {
+	filtered := make([]string, 0, len(names))
+
+	for _, candidate := range names {
+		if candidate != name {
+			filtered = append(filtered, candidate)
+		}
+	}
+
+	return filtered
+}
+
+func (p *SchemaPruner) matchRoots() ([]string, error) {
+	matched := make(map[string]bool)
+
+	for _, root := range p.roots {
+		if _, ok := p.doc.Components.Schemas[root]; ok {
+			matched[root] = true
+			continue
+		}
+
+		rootRegexp, err := regexp.Compile("^(?:" + root + ")$")
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid root %s", root)
+		}
+
+		found := false
+
+		for name := range p.doc.Components.Schemas {
+			if rootRegexp.MatchString(name) {
+				matched[name] = true
+				found = true
+			}
+		}
+
+		if !found {
+			return nil, fmt.Errorf("root %s matches no component schema", root)
+		}
+	}
+
+	names := make([]string, 0, len(matched))
+	for name := range matched {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names, nil
+}
+
+func (p *SchemaPruner) operationSchemaRefs() []*spec3.SchemaRef {
+	schemaRefs := make([]*spec3.SchemaRef, 0)
+
+	for _, op := range listOperations(p.doc, nil) {
+		for _, form := range listOperationForms(op) {
+			schemaRefs = append(schemaRefs, form.MediaType.Schema)
+		}
+
+		for _, stream := range listOperationStreams(op) {
+			schemaRefs = append(schemaRefs, stream.MediaType.Schema)
+		}
+	}
+
+	for _, problem := range listProblemResponses(p.doc, nil) {
+		schemaRefs = append(schemaRefs, problem.Schema)
+	}
+
+	for _, callback := range listCallbacks(p.doc, nil) {
+		if mediaType := getJSONMediaType(callback.Operation); mediaType != nil {
+			schemaRefs = append(schemaRefs, mediaType.Schema)
+		}
+	}
+
+	return schemaRefs
+}
@@ -3,12 +3,15 @@ package generator
 import (
 	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 
 	spec3 "github.com/getkin/kin-openapi/openapi3"
 )
 
 const (
 	GeneratedFilesPkgName = "openapi"
+	FileTypeName          = "FormFile"
 )
 
 type GoType struct {
@@ -22,50 +25,176 @@ type Prop struct {
 
 	GoType     *GoType
 	Name       string
+	SpecName   string
+	XMLTag     string
+	Tags       []string
 	IsRequired bool
+	Doc        []string
+
+	order *float64
+}
+
+// EnumType is the type of the enum values of the prop, the GoType without its pointer.
+func (p Prop) EnumType() string {
+	return strings.TrimPrefix(p.GoType.Name, "*")
+}
+
+func (p Prop) IsPointer() bool {
+	return strings.HasPrefix(p.GoType.Name, "*")
+}
+
+// EnumLiterals renders the enum values as Go literals of EnumType, null is left out since it is not a value of
+// the type. Enums of other than string, number and boolean types are not validated.
+func (p Prop) EnumLiterals() []string {
+	if p.Schema == nil || len(p.Enum) == 0 {
+		return nil
+	}
+
+	enumType := p.EnumType()
+
+	literals := make([]string, 0, len(p.Enum))
+
+	for _, value := range p.Enum {
+		var literal string
+
+		switch value := value.(type) {
+		case nil:
+			continue
+		case string:
+			if enumType != "string" {
+				return nil
+			}
+
+			literal = strconv.Quote(value)
+		case bool:
+			if enumType != "bool" {
+				return nil
+			}
+
+			literal = strconv.FormatBool(value)
+		case float64:
+			isInt := strings.HasPrefix(enumType, "int") || strings.HasPrefix(enumType, "uint")
+			if !isInt && !strings.HasPrefix(enumType, "float") || isInt && value != float64(int64(value)) {
+				return nil
+			}
+
+			literal = strconv.FormatFloat(value, 'f', -1, 64)
+		default:
+			return nil
+		}
+
+		literals = append(literals, literal)
+	}
+
+	if len(literals) == 0 {
+		return nil
+	}
+
+	return literals
 }
 
 type Model struct {
 	PkgName string
 	Name    string
+	Doc     []string
 	Props   []Prop
+	UsesXML bool
+	XMLName string
+
+	IsProblem     bool
+	ProblemTitle  *Prop
+	ProblemDetail *Prop
 }
 
 type SchemaResolver struct {
-	data map[string]*spec3.SchemaRef
+	doc    *spec3.T
+	naming Naming
+	data   map[string]*spec3.SchemaRef
 }
 
-func NewSchemaResolver(data map[string]*spec3.SchemaRef) *SchemaResolver {
+func NewSchemaResolver(doc *spec3.T, data map[string]*spec3.SchemaRef, naming Naming) *SchemaResolver {
 	return &SchemaResolver{
-		data: data,
+		doc:    doc,
+		data:   data,
+		naming: naming,
 	}
 }
 
 func (r *SchemaResolver) Resolve() map[string]*Model {
 	models := make(map[string]*Model)
+	problems := problemSchemas(r.doc, r.naming.initialisms)
 
 	for name, schemaRef := range r.data {
-		models[name] = &Model{
+		model := &Model{
 			PkgName: GeneratedFilesPkgName,
 			Name:    name,
+			Doc:     modelDoc(name, schemaRef.Value, r.naming.initialisms),
 			Props:   r.buildProps(name, schemaRef),
+			UsesXML: hasXML(schemaRef),
+			XMLName: xmlRootTag(name, schemaRef.Value),
 		}
+
+		sortPropsByOrder(model.Props)
+		reserved := reservedFieldNames
+		if problems[schemaRef.Value] {
+			reserved = problemReservedFieldNames
+		}
+
+		resolveFieldNames(model.Props, reserved)
+
+		if problems[schemaRef.Value] {
+			markProblemModel(model)
+		}
+
+		if model.UsesXML {
+			for i := range model.Props {
+				model.Props[i].Tags = append(model.Props[i].Tags, fmt.Sprintf(`xml:"%s"`, model.Props[i].XMLTag))
+			}
+		}
+
+		models[name] = model
 	}
 
 	return models
 }
 
+// markProblemModel makes the model of a problem implement error, its message made of the title and the detail when
+// they are strings.
+func markProblemModel(model *Model) {
+	model.IsProblem = true
+
+	for i := range model.Props {
+		prop := model.Props[i]
+		if prop.GoType.Name != "string" && prop.GoType.Name != "*string" {
+			continue
+		}
+
+		switch prop.SpecName {
+		case "title":
+			model.ProblemTitle = &prop
+		case "detail":
+			model.ProblemDetail = &prop
+		}
+	}
+}
+
 func (r *SchemaResolver) buildProps(name string, schemaRef *spec3.SchemaRef) []Prop {
 	props := make([]Prop, 0)
 
 	if schemaRef.Value.AllOf != nil {
+		merged := make(map[string]bool)
+
 		for _, elementSchemaRef := range schemaRef.Value.AllOf {
-			elementProps := r.buildProps(name, elementSchemaRef)
-			props = append(props, elementProps...)
+			for _, prop := range r.buildProps(name, elementSchemaRef) {
+				if !merged[prop.SpecName] {
+					merged[prop.SpecName] = true
+					props = append(props, prop)
+				}
+			}
 		}
 	} else {
-		for propName, propSchemaRef := range schemaRef.Value.Properties {
-			prop := r.mapSchemaRefToProp(name, schemaRef.Value, propName, propSchemaRef)
+		for _, propName := range orderedPropertyNames(schemaRef.Value) {
+			prop := r.mapSchemaRefToProp(name, schemaRef.Value, propName, schemaRef.Value.Properties[propName])
 			props = append(props, *prop)
 		}
 	}
@@ -93,18 +222,14 @@ func (r *SchemaResolver) mapSchemaRefToProp(parentName string, parentSchema *spe
 	if custom == nil {
 		prop = &Prop{
 			Schema:     schemaRef.Value,
-			Name:       propName(name),
+			Name:       propName(name, r.naming.initialisms),
+			SpecName:   name,
+			XMLTag:     xmlTag(name, schemaRef.Value),
 			GoType:     mapSimpleSchema2GoType(schemaRef.Value),
 			IsRequired: isPropRequired(parentSchema.Required, name),
 		}
 	} else {
-		var modelName string
-
-		if custom.Ref != "" {
-			modelName = refToModelName(custom.Ref)
-		} else {
-			modelName = embeddedObjectToModelName(parentName, name)
-		}
+		modelName := customSchemaModelName(parentName, name, custom, r.naming.initialisms)
 
 		referenced := r.findSchema(modelName)
 		if referenced == nil {
@@ -114,12 +239,17 @@ func (r *SchemaResolver) mapSchemaRefToProp(parentName string, parentSchema *spe
 
 		prop = &Prop{
 			Schema:     custom.Value,
-			Name:       propName(name),
+			Name:       propName(name, r.naming.initialisms),
+			SpecName:   name,
+			XMLTag:     xmlTag(name, schemaRef.Value),
 			GoType:     mapCustomSchemaToGoType(modelName, schemaRef.Value),
 			IsRequired: isPropRequired(parentSchema.Required, name),
 		}
 	}
 
+	prop.Doc = propDoc(schemaRef)
+	prop.order = schemaOrder(schemaRef.Value)
+
 	return prop
 }
 
@@ -148,6 +278,10 @@ func mapCustomSchemaToGoType(typeName string, schema *spec3.Schema) *GoType {
 }
 
 func mapScalarType2GoType(schema *spec3.Schema, isArr bool) *GoType {
+	if isBinary(schema) {
+		return mapBinaryType2GoType(isArr)
+	}
+
 	var goTypeStr string
 
 	switch schema.Type {
@@ -188,6 +322,22 @@ func mapScalarType2GoType(schema *spec3.Schema, isArr bool) *GoType {
 	}
 }
 
+func mapBinaryType2GoType(isArr bool) *GoType {
+	if isArr {
+		return &GoType{
+			Name:       "[]*" + FileTypeName,
+			IsNullable: true,
+			IsPtr:      false,
+		}
+	}
+
+	return &GoType{
+		Name:       "*" + FileTypeName,
+		IsNullable: true,
+		IsPtr:      true,
+	}
+}
+
 func mapSimpleSchema2GoType(schema *spec3.Schema) *GoType {
 	scalarGoType := mapScalarType2GoType(schema, false)
 	if scalarGoType != nil {
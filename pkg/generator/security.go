@@ -0,0 +1,52 @@
+package generator
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/iancoleman/strcase"
+
+	spec3 "github.com/getkin/kin-openapi/openapi3"
+)
+
+type SecuritySchemeModel struct {
+	Name       string
+	GoName     string
+	Type       string
+	In         string
+	ParamName  string
+	Scheme     string
+	AuthScheme string
+}
+
+func resolveSecuritySchemes(doc *spec3.T, extra initialisms) []SecuritySchemeModel {
+	names := make([]string, 0, len(doc.Components.SecuritySchemes))
+	for name := range doc.Components.SecuritySchemes {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	schemes := make([]SecuritySchemeModel, 0, len(names))
+
+	for _, name := range names {
+		schemeRef := doc.Components.SecuritySchemes[name]
+		if schemeRef.Value == nil {
+			continue
+		}
+
+		scheme := schemeRef.Value
+
+		schemes = append(schemes, SecuritySchemeModel{
+			Name:       name,
+			GoName:     goIdentifier(name, extra),
+			Type:       scheme.Type,
+			In:         scheme.In,
+			ParamName:  scheme.Name,
+			Scheme:     strings.ToLower(scheme.Scheme),
+			AuthScheme: strcase.ToCamel(scheme.Scheme),
+		})
+	}
+
+	return schemes
+}
@@ -0,0 +1,148 @@
+package generator
+
+import (
+	"go/token"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+
+	spec3 "github.com/getkin/kin-openapi/openapi3"
+)
+
+const (
+	RouterChi  = "chi"
+	RouterEcho = "echo"
+	RouterGin  = "gin"
+)
+
+var Routers = []string{RouterChi, RouterEcho, RouterGin}
+
+type ServerParam struct {
+	*URLParam
+
+	ArgName  string
+	Required bool
+}
+
+type ServerOperation struct {
+	Name        string
+	Method      string
+	Path        string
+	PathParams  []*ServerParam
+	Params      []*ServerParam
+	QueryParams []string
+	HasBody     bool
+}
+
+// HasQuery reports whether the handler of the operation has to parse the query string.
+func (o *ServerOperation) HasQuery() bool {
+	return len(o.QueryParams) > 0
+}
+
+type ServerResolver struct {
+	doc    *spec3.T
+	naming Naming
+}
+
+func NewServerResolver(doc *spec3.T, naming Naming) *ServerResolver {
+	return &ServerResolver{
+		doc:    doc,
+		naming: naming,
+	}
+}
+
+func (r *ServerResolver) Resolve() ([]*ServerOperation, error) {
+	operations := make([]*ServerOperation, 0)
+	extra := r.naming.initialisms
+
+	for _, op := range listOperations(r.doc, extra) {
+		operation := &ServerOperation{
+			Name:    op.Name,
+			Method:  strings.ToUpper(op.Method),
+			Path:    op.Path,
+			HasBody: op.RequestBody != nil,
+		}
+
+		args := map[string]bool{"w": true, "r": true, "params": true, "ctx": true, "contentType": true, "body": true}
+
+		for _, paramRef := range operationParameters(op) {
+			urlParam := buildURLParam(op, paramRef.Value, extra)
+
+			param := &ServerParam{
+				URLParam: urlParam,
+				Required: paramRef.Value.Required,
+			}
+
+			switch paramRef.Value.In {
+			case spec3.ParameterInPath:
+				param.ArgName = argName(urlParam.GoName, args)
+				operation.PathParams = append(operation.PathParams, param)
+			case spec3.ParameterInQuery:
+				operation.QueryParams = append(operation.QueryParams, urlParam.Name)
+				operation.Params = append(operation.Params, param)
+			default:
+				operation.Params = append(operation.Params, param)
+			}
+		}
+
+		operations = append(operations, operation)
+	}
+
+	return operations, nil
+}
+
+// importedPackages names the packages imported by the generated files, an argument named like one would shadow it.
+var importedPackages = map[string]bool{
+	"bufio": true, "bytes": true, "chi": true, "context": true, "echo": true, "embed": true, "errors": true,
+	"fmt": true, "gin": true, "http": true, "io": true, "json": true, "legacy": true, "log": true, "mime": true,
+	"multipart": true, "openapi3": true, "openapi3filter": true, "os": true, "reflect": true, "regexp": true,
+	"routers": true, "sort": true, "strconv": true, "strings": true, "sync": true, "textproto": true, "url": true,
+	"xml": true,
+}
+
+// argName turns the name of a path param into an argument of the server and client interface methods, avoiding
+// keywords, imported packages and the other arguments.
+func argName(goName string, taken map[string]bool) string {
+	name := lowerInitial(goName)
+
+	for token.IsKeyword(name) || importedPackages[name] || taken[name] {
+		name += "Param"
+	}
+
+	taken[name] = true
+
+	return name
+}
+
+// lowerInitial lower-cases the leading word of an identifier, a whole initialism like HTTP or ID included.
+func lowerInitial(goName string) string {
+	runes := []rune(goName)
+
+	upper := 0
+	for upper < len(runes) && unicode.IsUpper(runes[upper]) {
+		upper++
+	}
+
+	// in HTTPVersion the V starts the next word
+	if upper > 1 && upper < len(runes) && unicode.IsLower(runes[upper]) {
+		upper--
+	}
+
+	return strings.ToLower(string(runes[:upper])) + string(runes[upper:])
+}
+
+// ValidateRouter fails on routers without an adapter template, so callers can reject the option before generating.
+func ValidateRouter(router string) error {
+	if router == "" {
+		return nil
+	}
+
+	for _, known := range Routers {
+		if router == known {
+			return nil
+		}
+	}
+
+	return errors.Errorf("unknown router %s, use one of %s", router, strings.Join(Routers, ", "))
+}
@@ -0,0 +1,61 @@
+package generator
+
+import (
+	spec3 "github.com/getkin/kin-openapi/openapi3"
+)
+
+// StreamModel reads and writes the items of a streamed response, the writer sets ContentType as declared.
+type StreamModel struct {
+	Name          string
+	ContentType   string
+	ItemType      string
+	IsEventStream bool
+	HasValidate   bool
+}
+
+type StreamResolver struct {
+	doc    *spec3.T
+	naming Naming
+	models map[string]*Model
+}
+
+func NewStreamResolver(doc *spec3.T, models map[string]*Model, naming Naming) *StreamResolver {
+	return &StreamResolver{
+		doc:    doc,
+		models: models,
+		naming: naming,
+	}
+}
+
+func (r *StreamResolver) Resolve() []*StreamModel {
+	streams := make([]*StreamModel, 0)
+	extra := r.naming.initialisms
+
+	for _, op := range listOperations(r.doc, extra) {
+		for _, operationStream := range listOperationStreams(op) {
+			stream := &StreamModel{
+				Name:          operationStream.Name,
+				ContentType:   operationStream.ContentType,
+				IsEventStream: operationStream.IsEventStream,
+			}
+
+			schemaRef := operationStream.MediaType.Schema
+
+			if custom := getCustomTypeSchemaRef(schemaRef); custom != nil {
+				stream.ItemType = customSchemaModelName(stream.Name, "item", custom, extra)
+				_, stream.HasValidate = r.models[stream.ItemType]
+			} else {
+				itemSchema := schemaRef.Value
+				if isArray(itemSchema.Type) {
+					itemSchema = itemSchema.Items.Value
+				}
+
+				stream.ItemType = mapSimpleSchema2GoType(itemSchema).Name
+			}
+
+			streams = append(streams, stream)
+		}
+	}
+
+	return streams
+}
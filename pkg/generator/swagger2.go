@@ -0,0 +1,36 @@
+package generator
+
+import (
+	"encoding/json"
+
+	"github.com/getkin/kin-openapi/openapi2"
+	"github.com/getkin/kin-openapi/openapi2conv"
+	"github.com/invopop/yaml"
+)
+
+func isSwagger2(doc map[string]interface{}) bool {
+	version, _ := doc["swagger"].(string)
+
+	return version == "2.0"
+}
+
+// convertSwagger2 converts a Swagger 2.0 document into OpenAPI 3, so that `definitions` become
+// component schemas and body and formData parameters become request bodies.
+func convertSwagger2(data []byte) ([]byte, error) {
+	jsonData, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc2 openapi2.T
+	if err := json.Unmarshal(jsonData, &doc2); err != nil {
+		return nil, err
+	}
+
+	doc3, err := openapi2conv.ToV3(&doc2)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(doc3)
+}
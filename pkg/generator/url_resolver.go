@@ -0,0 +1,276 @@
+package generator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	spec3 "github.com/getkin/kin-openapi/openapi3"
+)
+
+type URLParam struct {
+	Name    string
+	GoName  string
+	GoType  string
+	In      string
+	Style   string
+	Explode bool
+	Object  *URLObjectModel
+
+	// rawReason tells why the schema couldn't be serialized when the param fell back to a raw string.
+	rawReason error
+}
+
+// URLObjectModel is the struct generated for an object param with fixed properties, params of every location get
+// one so the server interface can bind header and cookie objects too.
+type URLObjectModel struct {
+	Name  string
+	Props []URLObjectProp
+}
+
+type URLObjectProp struct {
+	Name   string
+	GoName string
+	GoType string
+	IsPtr  bool
+}
+
+type URLModel struct {
+	Name    string
+	Path    string
+	Params  []*URLParam
+	Objects []*URLObjectModel
+}
+
+type ServerVariableModel struct {
+	Name    string
+	Default string
+}
+
+type URLResolver struct {
+	doc      *spec3.T
+	naming   Naming
+	warnings []string
+}
+
+func NewURLResolver(doc *spec3.T, naming Naming) *URLResolver {
+	return &URLResolver{
+		doc:    doc,
+		naming: naming,
+	}
+}
+
+func (r *URLResolver) Resolve() ([]*URLModel, error) {
+	urls := make([]*URLModel, 0)
+	extra := r.naming.initialisms
+
+	for _, op := range listOperations(r.doc, extra) {
+		model := &URLModel{
+			Name: op.Name,
+			Path: op.Path,
+		}
+
+		for _, paramRef := range operationParameters(op) {
+			param := paramRef.Value
+
+			urlParam := buildURLParam(op, param, extra)
+			if urlParam.rawReason != nil {
+				r.warnings = append(r.warnings, fmt.Sprintf(
+					"%s param %s of operation %s is passed as a raw string: %v",
+					param.In, param.Name, op.Name, urlParam.rawReason,
+				))
+			}
+
+			if urlParam.Object != nil {
+				model.Objects = append(model.Objects, urlParam.Object)
+			}
+
+			if param.In == spec3.ParameterInPath || param.In == spec3.ParameterInQuery {
+				model.Params = append(model.Params, urlParam)
+			}
+		}
+
+		urls = append(urls, model)
+	}
+
+	return urls, nil
+}
+
+// Warnings lists the params of the last Resolve whose schema can't be serialized, they are typed as a raw string
+// holding the formatted value.
+func (r *URLResolver) Warnings() []string {
+	return r.warnings
+}
+
+func resolveServer(doc *spec3.T) (string, []ServerVariableModel) {
+	if len(doc.Servers) == 0 {
+		return "", nil
+	}
+
+	server := doc.Servers[0]
+
+	names := make([]string, 0, len(server.Variables))
+	for name := range server.Variables {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	variables := make([]ServerVariableModel, 0, len(names))
+
+	for _, name := range names {
+		variables = append(variables, ServerVariableModel{
+			Name:    name,
+			Default: server.Variables[name].Default,
+		})
+	}
+
+	return server.URL, variables
+}
+
+// buildURLParam falls back to a raw string param when the schema can't be serialized into a URL.
+func buildURLParam(op operation, param *spec3.Parameter, extra initialisms) *URLParam {
+	urlParam := &URLParam{
+		Name:   param.Name,
+		GoName: goIdentifier(param.Name, extra),
+		GoType: "string",
+		In:     param.In,
+		Style:  param.Style,
+	}
+
+	if urlParam.Style == "" {
+		urlParam.Style = defaultStyle(param.In)
+	}
+
+	urlParam.Explode = urlParam.Style == spec3.SerializationForm
+	if param.Explode != nil {
+		urlParam.Explode = *param.Explode
+	}
+
+	if param.Schema == nil {
+		if !param.Required {
+			urlParam.GoType = "*string"
+		}
+
+		return urlParam
+	}
+
+	schema := param.Schema.Value
+
+	goType, err := urlParamGoType(op, urlParam, schema, extra)
+	if err != nil {
+		urlParam.rawReason = err
+		urlParam.Style = defaultStyle(param.In)
+		urlParam.Explode = urlParam.Style == spec3.SerializationForm
+		urlParam.Object = nil
+		goType = &GoType{Name: "string"}
+	}
+
+	urlParam.GoType = goType.Name
+
+	if !param.Required && !goType.IsNullable && !strings.HasPrefix(goType.Name, "*") {
+		urlParam.GoType = "*" + goType.Name
+	}
+
+	return urlParam
+}
+
+func defaultStyle(in string) string {
+	if in == spec3.ParameterInQuery || in == spec3.ParameterInCookie {
+		return spec3.SerializationForm
+	}
+
+	return spec3.SerializationSimple
+}
+
+// urlParamGoType maps the schema of a path or query param to a type the URL builder can serialize, objects become
+// a generated struct or a map of scalars, other shapes are rejected with the reason.
+func urlParamGoType(op operation, urlParam *URLParam, schema *spec3.Schema, extra initialisms) (*GoType, error) {
+	if schema.AllOf != nil && len(schema.AllOf) == 1 {
+		return urlParamGoType(op, urlParam, schema.AllOf[0].Value, extra)
+	}
+
+	if schema.OneOf != nil || schema.AnyOf != nil {
+		return nil, errors.New("oneOf and anyOf schemas can't be serialized into a URL")
+	}
+
+	isObject := schema.Type == "object" || len(schema.Properties) > 0
+
+	if urlParam.Style == spec3.SerializationDeepObject && (!isObject || urlParam.In != spec3.ParameterInQuery) {
+		return nil, errors.New("the deepObject style only applies to query objects")
+	}
+
+	switch {
+	case isArray(schema.Type):
+		if schema.Items == nil || schema.Items.Value == nil || !isURLScalar(schema.Items.Value) {
+			return nil, errors.New("only arrays of scalars can be serialized into a URL")
+		}
+
+		return mapScalarType2GoType(schema.Items.Value, true), nil
+	case isObject:
+		if urlParam.Style == spec3.SerializationSpaceDelimited || urlParam.Style == spec3.SerializationPipeDelimited {
+			return nil, errors.Errorf("the %s style doesn't apply to objects", urlParam.Style)
+		}
+
+		if len(schema.Properties) > 0 {
+			object, err := urlObjectModel(op.Name+urlParam.GoName+"Param", schema, extra)
+			if err != nil {
+				return nil, err
+			}
+
+			urlParam.Object = object
+
+			return &GoType{Name: object.Name}, nil
+		}
+
+		valueType := "string"
+
+		if additional := schema.AdditionalProperties; additional != nil && additional.Value != nil {
+			if !isURLScalar(additional.Value) {
+				return nil, errors.New("only maps of scalars can be serialized into a URL")
+			}
+
+			valueType = mapScalarType2GoType(additional.Value, false).Name
+		}
+
+		return &GoType{Name: "map[string]" + valueType, IsNullable: true}, nil
+	case isURLScalar(schema):
+		return mapScalarType2GoType(schema, false), nil
+	}
+
+	return nil, errors.Errorf("schema of type %q can't be serialized into a URL", schema.Type)
+}
+
+func urlObjectModel(name string, schema *spec3.Schema, extra initialisms) (*URLObjectModel, error) {
+	object := &URLObjectModel{Name: name}
+
+	for _, propName := range orderedPropertyNames(schema) {
+		propSchema := schema.Properties[propName].Value
+		if !isURLScalar(propSchema) {
+			return nil, errors.Errorf("property %s of an object param must be a scalar", propName)
+		}
+
+		goType := mapScalarType2GoType(propSchema, false)
+		isPtr := goType.IsPtr
+
+		if !isPtr && !isPropRequired(schema.Required, propName) {
+			goType.Name = "*" + goType.Name
+			isPtr = true
+		}
+
+		object.Props = append(object.Props, URLObjectProp{
+			Name:   propName,
+			GoName: goIdentifier(propName, extra),
+			GoType: goType.Name,
+			IsPtr:  isPtr,
+		})
+	}
+
+	return object, nil
+}
+
+func isURLScalar(schema *spec3.Schema) bool {
+	return isScalar(schema.Type) && !isBinary(schema)
+}
@@ -1,6 +1,7 @@
 package generator
 
 import (
+	"encoding/json"
 	"path/filepath"
 	"strings"
 
@@ -48,10 +49,42 @@ func isScalar(tp string) bool {
 	return tp == "string" || tp == "integer" || tp == "boolean" || tp == "number"
 }
 
+func isBinary(schema *spec3.Schema) bool {
+	return schema.Type == "string" && schema.Format == "binary"
+}
+
 func isArray(tp string) bool {
 	return tp == "array"
 }
 
+func usesFileType(models map[string]*Model) bool {
+	for _, model := range models {
+		for _, prop := range model.Props {
+			if strings.TrimLeft(prop.GoType.Name, "[]*") == FileTypeName {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func usesContent(doc *spec3.T) bool {
+	for _, op := range listOperations(doc, nil) {
+		if op.RequestBody != nil && op.RequestBody.Value != nil && len(op.RequestBody.Value.Content) > 0 {
+			return true
+		}
+
+		for _, response := range op.Responses {
+			if response.Value != nil && len(response.Value.Content) > 0 {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
 func modelToFilename(modelName string) string {
 	return strcase.ToSnake(modelName)
 }
@@ -61,23 +94,70 @@ func getBaseFilename(path string) string {
 	return strings.TrimSuffix(fileName, filepath.Ext(fileName))
 }
 
-func refToModelName(ref string) string {
+func refToModelName(ref string, extra initialisms) string {
 	if strings.HasSuffix(ref, "yaml") || strings.HasSuffix(ref, "yml") {
-		return strcase.ToCamel(getBaseFilename(ref))
+		return goIdentifier(getBaseFilename(ref), extra)
 	}
 
 	parts := strings.Split(ref, "/")
-	return parts[len(parts)-1]
+	return goIdentifier(parts[len(parts)-1], extra)
 }
 
-func propToModelName(prop string) string {
-	return strcase.ToCamel(inflector.Singular(prop))
+func propToModelName(prop string, extra initialisms) string {
+	return goIdentifier(inflector.Singular(prop), extra)
 }
 
-func propName(prop string) string {
-	return strcase.ToCamel(prop)
+func propName(prop string, extra initialisms) string {
+	return goIdentifier(prop, extra)
 }
 
-func embeddedObjectToModelName(schemaName string, prop string) string {
-	return strcase.ToCamel(schemaName + "_" + inflector.Singular(prop))
+func customSchemaModelName(parentName string, name string, custom *spec3.SchemaRef, extra initialisms) string {
+	if goName := schemaGoName(custom.Value); goName != "" {
+		return goName
+	}
+
+	if custom.Ref != "" {
+		return refToModelName(custom.Ref, extra)
+	}
+
+	if parentName != "" {
+		return embeddedObjectToModelName(parentName, name, extra)
+	}
+
+	return propToModelName(name, extra)
+}
+
+func embeddedObjectToModelName(schemaName string, prop string, extra initialisms) string {
+	return goIdentifier(schemaName+"_"+inflector.Singular(prop), extra)
+}
+
+func decodeExtension(props spec3.ExtensionProps, name string, v interface{}) (bool, error) {
+	value, ok := props.Extensions[name]
+	if !ok {
+		return false, nil
+	}
+
+	raw, ok := value.(json.RawMessage)
+	if !ok {
+		var err error
+		if raw, err = json.Marshal(value); err != nil {
+			return true, err
+		}
+	}
+
+	return true, json.Unmarshal(raw, v)
+}
+
+func schemaGoName(schema *spec3.Schema) string {
+	var goName string
+
+	if schema == nil {
+		return ""
+	}
+
+	if _, err := decodeExtension(schema.ExtensionProps, GoNameExtension, &goName); err != nil {
+		return ""
+	}
+
+	return goName
 }
@@ -0,0 +1,73 @@
+package generator
+
+import (
+	spec3 "github.com/getkin/kin-openapi/openapi3"
+)
+
+func hasXML(schemaRef *spec3.SchemaRef) bool {
+	schema := schemaRef.Value
+
+	if schema.XML != nil {
+		return true
+	}
+
+	for _, elementSchemaRef := range schema.AllOf {
+		if hasXML(elementSchemaRef) {
+			return true
+		}
+	}
+
+	for _, propSchemaRef := range schema.Properties {
+		propSchema := propSchemaRef.Value
+
+		if propSchema.XML != nil || propSchema.Items != nil && propSchema.Items.Value.XML != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+func xmlRootTag(modelName string, schema *spec3.Schema) string {
+	if schema.XML == nil {
+		return ""
+	}
+
+	return xmlName(modelName, schema.XML, true)
+}
+
+func xmlTag(name string, schema *spec3.Schema) string {
+	if isArray(schema.Type) && schema.Items != nil {
+		itemName := xmlName(name, schema.Items.Value.XML, false)
+
+		if schema.XML != nil && schema.XML.Wrapped {
+			return xmlName(name, schema.XML, false) + ">" + itemName
+		}
+
+		return xmlName(name, schema.Items.Value.XML, true)
+	}
+
+	tag := xmlName(name, schema.XML, true)
+
+	if schema.XML != nil && schema.XML.Attribute {
+		tag += ",attr"
+	}
+
+	return tag
+}
+
+func xmlName(name string, xml *spec3.XML, withNamespace bool) string {
+	if xml == nil {
+		return name
+	}
+
+	if xml.Name != "" {
+		name = xml.Name
+	}
+
+	if withNamespace && xml.Namespace != "" {
+		name = xml.Namespace + " " + name
+	}
+
+	return name
+}
@@ -1,14 +1,22 @@
 package test
 
 import (
+	"encoding/json"
 	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
 	"testing"
 
+	"openapi3-go-gen/cmd/codegen/app"
 	"openapi3-go-gen/pkg/generator"
 
+	"github.com/iancoleman/strcase"
 	"github.com/kr/text"
 	"github.com/stretchr/testify/require"
 
@@ -26,7 +34,7 @@ components:
 `
 
 func beforeTest(t *testing.T) {
-	tmplPath, err := filepath.Abs("../pkg/generator/templates/struct.tmpl")
+	tmplPath, err := filepath.Abs("../pkg/generator/templates")
 	require.NoError(t, err)
 
 	genPath, err := filepath.Abs("gen")
@@ -53,23 +61,20 @@ func generate(yml string) error {
 		return err
 	}
 
-	l := spec3.NewLoader()
-	l.IsExternalRefsAllowed = true
-
-	doc, err := l.LoadFromData([]byte(oasStr))
+	doc, err := generator.LoadSpec("oas.yml")
 	if err != nil {
 		return err
 	}
 
-	flattener := generator.NewFlattener(doc)
+	flattener := generator.NewFlattener(doc, generator.Naming{})
 
 	flatSchemaRefs := flattener.Flatten()
 
-	schemaResolver := generator.NewSchemaResolver(flatSchemaRefs)
+	schemaResolver := generator.NewSchemaResolver(doc, flatSchemaRefs, generator.Naming{})
 
 	models := schemaResolver.Resolve()
 
-	gen := generator.NewGenerator()
+	gen := generator.NewGenerator(generator.Naming{})
 
 	err = gen.GenerateToFile(models, "gen")
 	if err != nil {
@@ -79,11 +84,208 @@ func generate(yml string) error {
 	return nil
 }
 
+func generateWithSpec(oasYaml string) (*spec3.T, error) {
+	return generateWithStrategy(oasYaml, generator.NamingStrategyFail)
+}
+
+func generateWithStrategy(oasYaml string, strategy generator.NamingStrategy) (*spec3.T, error) {
+	return generateWithOptions(oasYaml, strategy, "")
+}
+
+func generateWithOptions(oasYaml string, strategy generator.NamingStrategy, router string) (*spec3.T, error) {
+	return generateWithLoadOptions(oasYaml, generator.LoadOptions{}, strategy, router)
+}
+
+func generateWithLoadOptions(
+	oasYaml string,
+	loadOptions generator.LoadOptions,
+	strategy generator.NamingStrategy,
+	router string,
+) (*spec3.T, error) {
+	err := os.WriteFile("oas.yml", []byte(oasYaml), 0777)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := generator.LoadSpecWithOptions(loadOptions, "oas.yml")
+	if err != nil {
+		return nil, err
+	}
+
+	naming := loadOptions.Naming
+
+	modelNamer := generator.NewModelNamer(doc, strategy, naming)
+	modelNamer.Helpers = generator.HelperOptions{
+		EmbedSpec:       true,
+		MockServer:      true,
+		AsyncInterfaces: true,
+		Router:          router,
+	}
+
+	err = modelNamer.Resolve()
+	if err != nil {
+		return nil, err
+	}
+
+	gen := generator.NewGenerator(naming)
+
+	err = gen.GenerateSpecToFile(doc, "gen")
+	if err != nil {
+		return nil, err
+	}
+
+	models := generator.NewSchemaResolver(doc, generator.NewFlattener(doc, naming).Flatten(), naming).Resolve()
+
+	problems := generator.NewProblemResolver(doc, models, naming).Resolve()
+
+	err = gen.GenerateProblemsToFile(problems, "gen")
+	if err != nil {
+		return nil, err
+	}
+
+	err = gen.GenerateAuthToFile(doc, "gen")
+	if err != nil {
+		return nil, err
+	}
+
+	err = gen.GenerateContentToFile(doc, generator.NewContentResolver(doc, models, naming).Resolve(), "gen")
+	if err != nil {
+		return nil, err
+	}
+
+	forms, err := generator.NewFormResolver(doc, models, naming).Resolve()
+	if err != nil {
+		return nil, err
+	}
+
+	err = gen.GenerateFormsToFile(forms, models, "gen")
+	if err != nil {
+		return nil, err
+	}
+
+	streams := generator.NewStreamResolver(doc, models, naming).Resolve()
+
+	err = gen.GenerateStreamsToFile(streams, "gen")
+	if err != nil {
+		return nil, err
+	}
+
+	urls, err := generator.NewURLResolver(doc, naming).Resolve()
+	if err != nil {
+		return nil, err
+	}
+
+	err = gen.GenerateURLsToFile(doc, urls, "gen")
+	if err != nil {
+		return nil, err
+	}
+
+	serverOperations, err := generator.NewServerResolver(doc, naming).Resolve()
+	if err != nil {
+		return nil, err
+	}
+
+	err = gen.GenerateServerToFile(serverOperations, router, "gen")
+	if err != nil {
+		return nil, err
+	}
+
+	err = gen.GenerateClientToFile(serverOperations, problems, "gen")
+	if err != nil {
+		return nil, err
+	}
+
+	paginations, err := generator.NewPaginationResolver(doc, models, naming).Resolve()
+	if err != nil {
+		return nil, err
+	}
+
+	err = gen.GeneratePaginationsToFile(paginations, "gen")
+	if err != nil {
+		return nil, err
+	}
+
+	mockOperations, err := generator.NewMockResolver(doc, naming).Resolve()
+	if err != nil {
+		return nil, err
+	}
+
+	err = gen.GenerateMockToFile(mockOperations, "gen")
+	if err != nil {
+		return nil, err
+	}
+
+	callbacks := generator.NewCallbackResolver(doc, models, naming).Resolve()
+
+	err = gen.GenerateCallbacksToFile(doc, callbacks, "gen")
+	if err != nil {
+		return nil, err
+	}
+
+	channels, err := generator.NewAsyncResolver(doc).Resolve()
+	if err != nil {
+		return nil, err
+	}
+
+	err = gen.GenerateAsyncToFile(channels, "gen")
+	if err != nil {
+		return nil, err
+	}
+
+	err = gen.GenerateToFile(models, "gen")
+	if err != nil {
+		return nil, err
+	}
+
+	return doc, nil
+}
+
 func readGoFile(filename string) (string, error) {
 	file, err := os.ReadFile(fmt.Sprintf("gen/%s", filename))
 	return string(file), err
 }
 
+// testGenerated copies the generated package into a module of its own together with testFiles, then vets and tests it.
+func testGenerated(t *testing.T, testFiles map[string]string, requires ...string) {
+	dir := t.TempDir()
+
+	entries, err := os.ReadDir("gen")
+	require.NoError(t, err)
+
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".go" && entry.Name() != generator.SpecFilename {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join("gen", entry.Name()))
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, entry.Name()), data, 0666))
+	}
+
+	for name, content := range testFiles {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0666))
+	}
+
+	goMod := "module generated\n\ngo 1.18\n\nrequire github.com/getkin/kin-openapi v0.97.0\n"
+	for _, module := range requires {
+		goMod += "\nrequire " + module + "\n"
+	}
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte(goMod), 0666))
+
+	goSum, err := os.ReadFile("../go.sum")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.sum"), goSum, 0666))
+
+	for _, args := range [][]string{{"mod", "tidy"}, {"vet", "./..."}, {"test", "./..."}} {
+		cmd := exec.Command("go", args...)
+		cmd.Dir = dir
+
+		output, err := cmd.CombinedOutput()
+		require.NoError(t, err, "go %s:\n%s", strings.Join(args, " "), output)
+	}
+}
+
 func TestSimplestObject(t *testing.T) {
 	beforeTest(t)
 
@@ -100,12 +302,14 @@ Foo:
 `
 
 	expected := strings.TrimPrefix(`
+// Code generated by openapi3-go-gen. DO NOT EDIT.
+
 package openapi
 
 type Foo struct {
-	Str string
-	Num float64
-	Int int
+	Str string  `+"`json:\"str,omitempty\"`"+`
+	Num float64 `+"`json:\"num,omitempty\"`"+`
+	Int int     `+"`json:\"int,omitempty\"`"+`
 }
 
 func (instance *Foo) Validate() error {
@@ -139,10 +343,12 @@ Bar:
 `
 
 	expectedFoo := strings.TrimPrefix(`
+// Code generated by openapi3-go-gen. DO NOT EDIT.
+
 package openapi
 
 type Foo struct {
-	Bar Bar
+	Bar Bar `+"`json:\"bar,omitempty\"`"+`
 }
 
 func (instance *Foo) Validate() error {
@@ -151,10 +357,12 @@ func (instance *Foo) Validate() error {
 `, "\n")
 
 	expectedBar := strings.TrimPrefix(`
+// Code generated by openapi3-go-gen. DO NOT EDIT.
+
 package openapi
 
 type Bar struct {
-	Name string
+	Name string `+"`json:\"name,omitempty\"`"+`
 }
 
 func (instance *Bar) Validate() error {
@@ -190,10 +398,12 @@ Foo:
 `
 
 	expectedFoo := strings.TrimPrefix(`
+// Code generated by openapi3-go-gen. DO NOT EDIT.
+
 package openapi
 
 type Foo struct {
-	Bar FooBar
+	Bar FooBar `+"`json:\"bar,omitempty\"`"+`
 }
 
 func (instance *Foo) Validate() error {
@@ -202,10 +412,12 @@ func (instance *Foo) Validate() error {
 `, "\n")
 
 	expectedBar := strings.TrimPrefix(`
+// Code generated by openapi3-go-gen. DO NOT EDIT.
+
 package openapi
 
 type FooBar struct {
-	Name string
+	Name string `+"`json:\"name,omitempty\"`"+`
 }
 
 func (instance *FooBar) Validate() error {
@@ -247,11 +459,13 @@ Baz:
 `
 
 	expectedFoo := strings.TrimPrefix(`
+// Code generated by openapi3-go-gen. DO NOT EDIT.
+
 package openapi
 
 type Foo struct {
-	Baz interface{}
-	Bar string
+	Bar string      `+"`json:\"bar,omitempty\"`"+`
+	Baz interface{} `+"`json:\"baz,omitempty\"`"+`
 }
 
 func (instance *Foo) Validate() error {
@@ -260,10 +474,12 @@ func (instance *Foo) Validate() error {
 `, "\n")
 
 	expectedBaz := strings.TrimPrefix(`
+// Code generated by openapi3-go-gen. DO NOT EDIT.
+
 package openapi
 
 type Baz struct {
-	Name string
+	Name string `+"`json:\"name,omitempty\"`"+`
 }
 
 func (instance *Baz) Validate() error {
@@ -304,11 +520,13 @@ Baz:
 `
 
 	expectedFoo := strings.TrimPrefix(`
+// Code generated by openapi3-go-gen. DO NOT EDIT.
+
 package openapi
 
 type Foo struct {
-	Baz interface{}
-	Bar string
+	Bar string      `+"`json:\"bar,omitempty\"`"+`
+	Baz interface{} `+"`json:\"baz,omitempty\"`"+`
 }
 
 func (instance *Foo) Validate() error {
@@ -317,10 +535,12 @@ func (instance *Foo) Validate() error {
 `, "\n")
 
 	expectedBaz := strings.TrimPrefix(`
+// Code generated by openapi3-go-gen. DO NOT EDIT.
+
 package openapi
 
 type Baz struct {
-	Name string
+	Name string `+"`json:\"name,omitempty\"`"+`
 }
 
 func (instance *Baz) Validate() error {
@@ -362,11 +582,13 @@ Baz:
 `
 
 	expectedFoo := strings.TrimPrefix(`
+// Code generated by openapi3-go-gen. DO NOT EDIT.
+
 package openapi
 
 type Foo struct {
-	Baz interface{}
-	Bar string
+	Bar string      `+"`json:\"bar,omitempty\"`"+`
+	Baz interface{} `+"`json:\"baz,omitempty\"`"+`
 }
 
 func (instance *Foo) Validate() error {
@@ -375,10 +597,12 @@ func (instance *Foo) Validate() error {
 `, "\n")
 
 	expectedBaz := strings.TrimPrefix(`
+// Code generated by openapi3-go-gen. DO NOT EDIT.
+
 package openapi
 
 type Baz struct {
-	Name string
+	Name string `+"`json:\"name,omitempty\"`"+`
 }
 
 func (instance *Baz) Validate() error {
@@ -414,11 +638,13 @@ Foo:
 `
 
 	expectedFoo := strings.TrimPrefix(`
+// Code generated by openapi3-go-gen. DO NOT EDIT.
+
 package openapi
 
 type Foo struct {
-	Baz *float64
-	Bar string
+	Bar string   `+"`json:\"bar,omitempty\"`"+`
+	Baz *float64 `+"`json:\"baz,omitempty\"`"+`
 }
 
 func (instance *Foo) Validate() error {
@@ -456,11 +682,13 @@ Bar:
 `
 
 	expectedFoo := strings.TrimPrefix(`
+// Code generated by openapi3-go-gen. DO NOT EDIT.
+
 package openapi
 
 type Foo struct {
-	Name string
-	Bars []Bar
+	Name string `+"`json:\"name,omitempty\"`"+`
+	Bars []Bar  `+"`json:\"bars,omitempty\"`"+`
 }
 
 func (instance *Foo) Validate() error {
@@ -469,10 +697,12 @@ func (instance *Foo) Validate() error {
 `, "\n")
 
 	expectedBar := strings.TrimPrefix(`
+// Code generated by openapi3-go-gen. DO NOT EDIT.
+
 package openapi
 
 type Bar struct {
-	Age int
+	Age int `+"`json:\"age,omitempty\"`"+`
 }
 
 func (instance *Bar) Validate() error {
@@ -509,11 +739,13 @@ Foo:
 `
 
 	expectedFoo := strings.TrimPrefix(`
+// Code generated by openapi3-go-gen. DO NOT EDIT.
+
 package openapi
 
 type Foo struct {
-	Name string
-	Bars []int
+	Name string `+"`json:\"name,omitempty\"`"+`
+	Bars []int  `+"`json:\"bars,omitempty\"`"+`
 }
 
 func (instance *Foo) Validate() error {
@@ -549,11 +781,13 @@ Foo:
 `
 
 	expectedFoo := strings.TrimPrefix(`
+// Code generated by openapi3-go-gen. DO NOT EDIT.
+
 package openapi
 
 type Foo struct {
-	Name string
-	Bars []FooBar
+	Name string   `+"`json:\"name,omitempty\"`"+`
+	Bars []FooBar `+"`json:\"bars,omitempty\"`"+`
 }
 
 func (instance *Foo) Validate() error {
@@ -562,10 +796,12 @@ func (instance *Foo) Validate() error {
 `, "\n")
 
 	expectedBar := strings.TrimPrefix(`
+// Code generated by openapi3-go-gen. DO NOT EDIT.
+
 package openapi
 
 type FooBar struct {
-	Zoo string
+	Zoo string `+"`json:\"zoo,omitempty\"`"+`
 }
 
 func (instance *FooBar) Validate() error {
@@ -603,11 +839,13 @@ Foo:
 `
 
 	expectedFoo := strings.TrimPrefix(`
+// Code generated by openapi3-go-gen. DO NOT EDIT.
+
 package openapi
 
 type Foo struct {
-	Name string
-	Bars []string
+	Name string   `+"`json:\"name,omitempty\"`"+`
+	Bars []string `+"`json:\"bars,omitempty\"`"+`
 }
 
 func (instance *Foo) Validate() error {
@@ -641,11 +879,13 @@ Foo:
 `
 
 	expectedFoo := strings.TrimPrefix(`
+// Code generated by openapi3-go-gen. DO NOT EDIT.
+
 package openapi
 
 type Foo struct {
-	Name string
-	Bars []string
+	Name string   `+"`json:\"name,omitempty\"`"+`
+	Bars []string `+"`json:\"bars,omitempty\"`"+`
 }
 
 func (instance *Foo) Validate() error {
@@ -687,11 +927,13 @@ Bar:
 `
 
 	expectedFoo := strings.TrimPrefix(`
+// Code generated by openapi3-go-gen. DO NOT EDIT.
+
 package openapi
 
 type Foo struct {
-	Plum FooPlum
-	Name string
+	Name string  `+"`json:\"name,omitempty\"`"+`
+	Plum FooPlum `+"`json:\"plum,omitempty\"`"+`
 }
 
 func (instance *Foo) Validate() error {
@@ -700,10 +942,12 @@ func (instance *Foo) Validate() error {
 `, "\n")
 
 	expectedBar := strings.TrimPrefix(`
+// Code generated by openapi3-go-gen. DO NOT EDIT.
+
 package openapi
 
 type Bar struct {
-	Bazzer string
+	Bazzer string `+"`json:\"bazzer,omitempty\"`"+`
 }
 
 func (instance *Bar) Validate() error {
@@ -712,11 +956,13 @@ func (instance *Bar) Validate() error {
 `, "\n")
 
 	expectedPlum := strings.TrimPrefix(`
+// Code generated by openapi3-go-gen. DO NOT EDIT.
+
 package openapi
 
 type FooPlum struct {
-	Kek    *string
-	Bazzer string
+	Bazzer string  `+"`json:\"bazzer,omitempty\"`"+`
+	Kek    *string `+"`json:\"kek,omitempty\"`"+`
 }
 
 func (instance *FooPlum) Validate() error {
@@ -761,11 +1007,13 @@ Bar:
 `
 
 	expectedFoo := strings.TrimPrefix(`
+// Code generated by openapi3-go-gen. DO NOT EDIT.
+
 package openapi
 
 type Foo struct {
-	Plum Bar
-	Name string
+	Name string `+"`json:\"name,omitempty\"`"+`
+	Plum Bar    `+"`json:\"plum,omitempty\"`"+`
 }
 
 func (instance *Foo) Validate() error {
@@ -774,10 +1022,12 @@ func (instance *Foo) Validate() error {
 `, "\n")
 
 	expectedBar := strings.TrimPrefix(`
+// Code generated by openapi3-go-gen. DO NOT EDIT.
+
 package openapi
 
 type Bar struct {
-	Bazzer string
+	Bazzer string `+"`json:\"bazzer,omitempty\"`"+`
 }
 
 func (instance *Bar) Validate() error {
@@ -816,11 +1066,13 @@ Foo:
 `
 
 	expectedFoo := strings.TrimPrefix(`
+// Code generated by openapi3-go-gen. DO NOT EDIT.
+
 package openapi
 
 type Foo struct {
-	Plum FooPlum
-	Name string
+	Name string  `+"`json:\"name,omitempty\"`"+`
+	Plum FooPlum `+"`json:\"plum,omitempty\"`"+`
 }
 
 func (instance *Foo) Validate() error {
@@ -829,10 +1081,12 @@ func (instance *Foo) Validate() error {
 `, "\n")
 
 	expectedPlum := strings.TrimPrefix(`
+// Code generated by openapi3-go-gen. DO NOT EDIT.
+
 package openapi
 
 type FooPlum struct {
-	IsAgree bool
+	IsAgree bool `+"`json:\"is_agree,omitempty\"`"+`
 }
 
 func (instance *FooPlum) Validate() error {
@@ -869,6 +1123,8 @@ Foo:
 `
 
 	expectedFoo := strings.TrimPrefix(`
+// Code generated by openapi3-go-gen. DO NOT EDIT.
+
 package openapi
 
 import (
@@ -876,8 +1132,8 @@ import (
 )
 
 type Foo struct {
-	Name     string
-	LastName *string
+	Name     string  `+"`json:\"name\"`"+`
+	LastName *string `+"`json:\"last_name\"`"+`
 }
 
 func (instance *Foo) Validate() error {
@@ -914,6 +1170,8 @@ Foo:
 `
 
 	expectedFoo := strings.TrimPrefix(`
+// Code generated by openapi3-go-gen. DO NOT EDIT.
+
 package openapi
 
 import (
@@ -921,7 +1179,8 @@ import (
 )
 
 type Foo struct {
-	Name string
+	// Constraints: minLength 3, maxLength 10
+	Name string `+"`json:\"name,omitempty\"`"+`
 }
 
 func (instance *Foo) Validate() error {
@@ -958,6 +1217,8 @@ Foo:
 `
 
 	expectedFoo := strings.TrimPrefix(`
+// Code generated by openapi3-go-gen. DO NOT EDIT.
+
 package openapi
 
 import (
@@ -965,7 +1226,8 @@ import (
 )
 
 type Foo struct {
-	Name int
+	// Constraints: minimum 3, maximum 10
+	Name int `+"`json:\"name,omitempty\"`"+`
 }
 
 func (instance *Foo) Validate() error {
@@ -1004,6 +1266,8 @@ Foo:
 `
 
 	expectedFoo := strings.TrimPrefix(`
+// Code generated by openapi3-go-gen. DO NOT EDIT.
+
 package openapi
 
 import (
@@ -1011,7 +1275,8 @@ import (
 )
 
 type Foo struct {
-	Name int
+	// Constraints: exclusive minimum 3, exclusive maximum 10
+	Name int `+"`json:\"name,omitempty\"`"+`
 }
 
 func (instance *Foo) Validate() error {
@@ -1047,6 +1312,8 @@ Foo:
 `
 
 	expectedFoo := strings.TrimPrefix(`
+// Code generated by openapi3-go-gen. DO NOT EDIT.
+
 package openapi
 
 import (
@@ -1055,7 +1322,8 @@ import (
 )
 
 type Foo struct {
-	Name string
+	// Constraints: pattern ^\d{3}-\d{2}-\d{4}$
+	Name string `+"`json:\"name,omitempty\"`"+`
 }
 
 func (instance *Foo) Validate() error {
@@ -1091,6 +1359,8 @@ Foo:
 `
 
 	expectedFoo := strings.TrimPrefix(`
+// Code generated by openapi3-go-gen. DO NOT EDIT.
+
 package openapi
 
 import (
@@ -1098,8 +1368,10 @@ import (
 )
 
 type Foo struct {
-	Name  string
-	Level float64
+	// Constraints: one of Katty, Petty
+	Name string `+"`json:\"name,omitempty\"`"+`
+	// Constraints: one of 1.1, 2.2
+	Level float64 `+"`json:\"level,omitempty\"`"+`
 }
 
 func (instance *Foo) Validate() error {
@@ -1116,7 +1388,7 @@ func (instance *Foo) Validate() error {
 		return errors.New("Value for field Name is not allowed")
 	}
 	containsLevel := false
-	enumLevel := []float64{"1.1", "2.2"}
+	enumLevel := []float64{1.1, 2.2}
 	for _, v := range enumLevel {
 		if v == instance.Level {
 			containsLevel = true
@@ -1139,3 +1411,6037 @@ func (instance *Foo) Validate() error {
 
 	require.Equal(t, expectedFoo, foo)
 }
+
+func TestEmbedSpec(t *testing.T) {
+	beforeTest(t)
+
+	oasYaml := `
+openapi: "3.0.0"
+info:
+  title: "Test"
+  version: "1.0.0"
+paths:
+  /foos/{id}:
+    get:
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema:
+            type: integer
+      responses:
+        "200":
+          description: OK
+          headers:
+            X-Rate-Limit:
+              required: true
+              schema:
+                type: integer
+          content:
+            application/json:
+              schema:
+                $ref: "#/components/schemas/Foo"
+components:
+  schemas:
+    Foo:
+      type: object
+      x-go-name: Foo
+      x-owner: pets
+      properties:
+        bar:
+          $ref: "gen/bar.yaml"
+`
+
+	barYaml := `
+type: object
+properties:
+  name:
+    type: string
+`
+
+	err := os.WriteFile("gen/bar.yaml", []byte(barYaml), 0777)
+	require.NoError(t, err)
+
+	doc, err := generateWithSpec(oasYaml)
+	require.NoError(t, err)
+
+	require.Equal(t, "#/components/schemas/Bar", doc.Components.Schemas["Foo"].Value.Properties["bar"].Ref)
+	require.Contains(t, doc.Components.Schemas, "Bar")
+
+	specGo, err := readGoFile("spec.go")
+	require.NoError(t, err)
+	require.Contains(t, specGo, "//go:embed openapi.json\n")
+	require.Contains(t, specGo, "func ValidationMiddleware(options ValidationOptions) func(http.Handler) http.Handler {")
+
+	specJSON, err := readGoFile("openapi.json")
+	require.NoError(t, err)
+	require.NotContains(t, specJSON, "bar.yaml")
+	require.Contains(t, specJSON, `"$ref": "#/components/schemas/Foo"`)
+	require.NotContains(t, specJSON, "x-go-")
+	require.Contains(t, specJSON, `"x-owner": "pets"`)
+	require.Contains(t, doc.Components.Schemas["Foo"].Value.Extensions, "x-go-name")
+
+	responseValidationGo, err := readGoFile("response_validation.go")
+	require.NoError(t, err)
+	require.Contains(t, responseValidationGo, "func (t *ValidatingTransport) RoundTrip(req *http.Request) (*http.Response, error) {")
+	require.Contains(t, responseValidationGo, "func ResponseValidationMiddleware(options ResponseValidationOptions) func(http.Handler) http.Handler {")
+
+	contentGo, err := readGoFile("content.go")
+	require.NoError(t, err)
+	require.Contains(t, contentGo, "func MarshalContent(contentType string, v interface{}) ([]byte, error) {")
+	require.Contains(t, contentGo, "func UnmarshalContent(contentType string, data []byte, v interface{}) error {")
+
+	bundled, err := spec3.NewLoader().LoadFromData([]byte(specJSON))
+	require.NoError(t, err)
+	require.Equal(t, "string", bundled.Components.Schemas["Foo"].Value.Properties["bar"].Value.Properties["name"].Value.Type)
+}
+
+func TestSecuritySchemes(t *testing.T) {
+	beforeTest(t)
+
+	oasYaml := `
+openapi: "3.0.0"
+info:
+  title: "Test"
+  version: "1.0.0"
+paths: {}
+components:
+  securitySchemes:
+    api_key:
+      type: apiKey
+      in: query
+      name: key
+    basic:
+      type: http
+      scheme: basic
+    bearer:
+      type: http
+      scheme: bearer
+    oauth:
+      type: oauth2
+      flows:
+        clientCredentials:
+          tokenUrl: https://example.com/token
+          scopes: {}
+`
+
+	expectedOptions := []string{`
+func WithAPIKey(key string) RequestEditorFn {
+	return func(ctx context.Context, req *http.Request) error {
+		query := req.URL.Query()
+		query.Set("key", key)
+		req.URL.RawQuery = query.Encode()
+		return nil
+	}
+}
+`, `
+func WithBasic(username string, password string) RequestEditorFn {
+	return func(ctx context.Context, req *http.Request) error {
+		req.SetBasicAuth(username, password)
+		return nil
+	}
+}
+`, `
+func WithBearer(token string) RequestEditorFn {
+	return func(ctx context.Context, req *http.Request) error {
+		req.Header.Set("Authorization", "Bearer "+token)
+		return nil
+	}
+}
+`, `
+func WithOauth(source TokenSource) RequestEditorFn {
+	return func(ctx context.Context, req *http.Request) error {
+		token, err := source.Token(ctx)
+		if err != nil {
+			return err
+		}
+
+		req.Header.Set("Authorization", "Bearer "+token)
+		return nil
+	}
+}
+`}
+
+	err := os.WriteFile("oas.yml", []byte(oasYaml), 0777)
+	require.NoError(t, err)
+
+	doc, err := generator.LoadSpec("oas.yml")
+	require.NoError(t, err)
+
+	require.NoError(t, os.MkdirAll("gen", 0777))
+	require.NoError(t, generator.NewGenerator(generator.Naming{}).GenerateAuthToFile(doc, "gen"))
+
+	authGo, err := readGoFile("auth.go")
+	require.NoError(t, err)
+	require.Contains(t, authGo, "func WithAPIKey(key string) RequestEditorFn {")
+	require.Contains(t, authGo, "func WithOauth(source TokenSource) RequestEditorFn {")
+
+	_, err = readGoFile("spec.go")
+	require.True(t, os.IsNotExist(err))
+
+	_, err = generateWithSpec(oasYaml)
+	require.NoError(t, err)
+
+	authGo, err = readGoFile("auth.go")
+	require.NoError(t, err)
+
+	for _, expected := range expectedOptions {
+		require.Contains(t, authGo, expected)
+	}
+
+	securityGo, err := readGoFile("security.go")
+	require.NoError(t, err)
+	require.Contains(t, securityGo, "func SecurityMiddleware(authenticator Authenticator) func(http.Handler) http.Handler {")
+}
+
+func TestContentCodecs(t *testing.T) {
+	beforeTest(t)
+
+	err := os.WriteFile("oas.yml", []byte(validationOasYaml), 0777)
+	require.NoError(t, err)
+
+	doc, err := generator.LoadSpec("oas.yml")
+	require.NoError(t, err)
+
+	require.NoError(t, os.MkdirAll("gen", 0777))
+	require.NoError(t, generator.NewGenerator(generator.Naming{}).GenerateContentToFile(doc, nil, "gen"))
+
+	contentGo, err := readGoFile("content.go")
+	require.NoError(t, err)
+	require.Contains(t, contentGo, "func MarshalContent(contentType string, v interface{}) ([]byte, error) {")
+
+	_, err = readGoFile("spec.go")
+	require.True(t, os.IsNotExist(err))
+
+	require.NoError(t, os.RemoveAll("gen"))
+	require.NoError(t, os.MkdirAll("gen", 0777))
+
+	doc.Paths = spec3.Paths{}
+	require.NoError(t, generator.NewGenerator(generator.Naming{}).GenerateContentToFile(doc, nil, "gen"))
+
+	_, err = readGoFile("content.go")
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestJSONWireNamesRuntime(t *testing.T) {
+	beforeTest(t)
+
+	oasYaml := `
+openapi: 3.0.3
+info:
+  title: Pets
+  version: 1.0.0
+paths:
+  /pets:
+    post:
+      operationId: createPet
+      responses:
+        '201':
+          description: Created
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/Pet'
+components:
+  schemas:
+    Pet:
+      type: object
+      required:
+        - id
+        - name
+      properties:
+        id:
+          type: integer
+        name:
+          type: string
+        callbackUrl:
+          type: string
+        kind:
+          type: string
+          enum:
+            - cat
+            - dog
+`
+
+	_, err := generateWithSpec(oasYaml)
+	require.NoError(t, err)
+
+	testGenerated(t, map[string]string{"wire_test.go": `package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestWireNames(t *testing.T) {
+	data, err := json.Marshal(Pet{ID: 1, Name: "rex", CallbackURL: "https://example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fields := make(map[string]interface{})
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatal(err)
+	}
+
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	if expected := []string{"callbackUrl", "id", "name"}; !reflect.DeepEqual(keys, expected) {
+		t.Errorf("got keys %v, expected %v", keys, expected)
+	}
+}
+
+func TestEncodedResponseIsValid(t *testing.T) {
+	handler := ResponseValidationMiddleware(ResponseValidationOptions{Mode: ResponseValidationFail})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_ = EncodeCreatePetResponse(w, r, Pet{ID: 1, Name: "rex"})
+		}),
+	)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/pets", nil))
+
+	if rec.Code != http.StatusCreated {
+		t.Errorf("got status %d: %s", rec.Code, rec.Body.String())
+	}
+}
+`})
+}
+
+func TestContentNegotiationRuntime(t *testing.T) {
+	beforeTest(t)
+
+	oasYaml := `
+openapi: 3.0.3
+info:
+  title: Pets
+  version: 1.0.0
+paths:
+  /pets:
+    post:
+      operationId: createPet
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/Pet'
+          application/xml:
+            schema:
+              $ref: '#/components/schemas/Pet'
+      responses:
+        '201':
+          description: Created
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/Pet'
+            application/xml:
+              schema:
+                $ref: '#/components/schemas/Pet'
+  /pets/{id}/photo:
+    get:
+      operationId: getPetPhoto
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema:
+            type: integer
+      responses:
+        '200':
+          description: OK
+          content:
+            application/octet-stream:
+              schema:
+                type: string
+                format: binary
+    put:
+      operationId: uploadPetPhoto
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema:
+            type: integer
+      requestBody:
+        required: true
+        content:
+          application/octet-stream: {}
+      responses:
+        '204':
+          description: Uploaded
+  /pets/{id}/export:
+    get:
+      operationId: exportPet
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema:
+            type: integer
+      responses:
+        '200':
+          description: OK
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/Pet'
+            application/octet-stream:
+              schema:
+                type: string
+                format: binary
+            text/csv:
+              schema:
+                type: string
+  /count:
+    get:
+      operationId: getCount
+      responses:
+        '200':
+          description: OK
+          content:
+            text/plain:
+              schema:
+                type: integer
+  /greeting:
+    get:
+      operationId: getGreeting
+      responses:
+        '200':
+          description: OK
+          content:
+            text/plain:
+              schema:
+                type: string
+components:
+  schemas:
+    Pet:
+      type: object
+      xml:
+        name: pet
+      required:
+        - name
+      properties:
+        id:
+          type: integer
+          xml:
+            attribute: true
+        name:
+          type: string
+`
+
+	expectedContent := []string{`
+func EncodeCreatePetRequest(contentType string, body Pet) (io.Reader, error) {
+	if err := checkContentType(contentType, []string{"application/json", "application/xml"}); err != nil {
+		return nil, err
+	}
+`, `
+func DecodeCreatePetRequest(r *http.Request) (Pet, error) {`, `
+func EncodeCreatePetResponse(w http.ResponseWriter, r *http.Request, body Pet) error {
+	return encodeResponse(w, r, 201, []string{"application/json", "application/xml"}, body)
+}
+`, `
+func DecodeGetPetPhotoResponse(resp *http.Response) ([]byte, error) {`, `
+func EncodeGetGreetingResponse(w http.ResponseWriter, r *http.Request, body string) error {
+	return encodeResponse(w, r, 200, []string{"text/plain"}, body)
+}
+`, `
+func EncodeExportPetResponse(w http.ResponseWriter, r *http.Request, body Pet) error {
+	return encodeResponse(w, r, 200, []string{"application/json"}, body)
+}
+`, `
+func EncodeExportPetResponseOctetStream(w http.ResponseWriter, r *http.Request, body []byte) error {
+	return encodeResponse(w, r, 200, []string{"application/octet-stream"}, body)
+}
+`, `
+func EncodeExportPetResponseCsv(w http.ResponseWriter, r *http.Request, body string) error {
+	return encodeResponse(w, r, 200, []string{"text/csv"}, body)
+}
+`, `
+func DecodeUploadPetPhotoRequestReader(r *http.Request) (io.Reader, error) {`, `
+func DecodeGetPetPhotoResponseReader(resp *http.Response) (io.ReadCloser, error) {`}
+
+	doc, err := generateWithSpec(oasYaml)
+	require.NoError(t, err)
+
+	naming := generator.Naming{}
+	models := generator.NewSchemaResolver(doc, generator.NewFlattener(doc, naming).Flatten(), naming).Resolve()
+
+	contentResolver := generator.NewContentResolver(doc, models, naming)
+	contentResolver.Resolve()
+	require.Equal(t, []string{"operation GetCount has no codec for the response text/plain"}, contentResolver.Warnings())
+
+	content, err := readGoFile("content.go")
+	require.NoError(t, err)
+
+	for _, expected := range expectedContent {
+		require.Contains(t, content, expected)
+	}
+
+	testGenerated(t, map[string]string{"content_test.go": `package openapi
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type acceptTransport string
+
+func (t acceptTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req.Header.Set("Accept", string(t))
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func newContentServer() *httptest.Server {
+	mock := NewMockServer()
+	mock.CreatePetFunc = func(w http.ResponseWriter, r *http.Request) {
+		pet, err := DecodeCreatePetRequest(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+			return
+		}
+
+		pet.ID = 7
+
+		if err := EncodeCreatePetResponse(w, r, pet); err != nil {
+			http.Error(w, err.Error(), http.StatusNotAcceptable)
+		}
+	}
+	mock.GetPetPhotoFunc = func(w http.ResponseWriter, r *http.Request, id int) {
+		_ = EncodeGetPetPhotoResponseReader(w, r, bytes.NewReader([]byte{0x89, 'P', 'N', 'G'}))
+	}
+	mock.UploadPetPhotoFunc = func(w http.ResponseWriter, r *http.Request, id int) {
+		body, err := DecodeUploadPetPhotoRequestReader(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+			return
+		}
+
+		if photo, err := io.ReadAll(body); err != nil || !bytes.Equal(photo, []byte{0x89, 'P', 'N', 'G'}) {
+			http.Error(w, "unexpected photo", http.StatusBadRequest)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+	mock.ExportPetFunc = func(w http.ResponseWriter, r *http.Request, id int) {
+		switch r.Header.Get("Accept") {
+		case "text/csv":
+			_ = EncodeExportPetResponseCsv(w, r, "id,name\n7,Rex\n")
+		case "application/octet-stream":
+			_ = EncodeExportPetResponseOctetStream(w, r, []byte("Rex"))
+		default:
+			_ = EncodeExportPetResponse(w, r, Pet{ID: 7, Name: "Rex"})
+		}
+	}
+	mock.GetGreetingFunc = func(w http.ResponseWriter, r *http.Request) {
+		_ = EncodeGetGreetingResponse(w, r, "hello")
+	}
+
+	return httptest.NewServer(mock)
+}
+
+func TestXMLRoundTrip(t *testing.T) {
+	server := newContentServer()
+	defer server.Close()
+
+	client := &Client{URLs: &URLBuilder{ServerURL: server.URL}, HTTPClient: &http.Client{Transport: acceptTransport("application/xml")}}
+
+	body, err := EncodeCreatePetRequest("application/xml", Pet{Name: "Rex"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := client.CreatePet(context.Background(), "application/xml", body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if contentType := resp.Header.Get("Content-Type"); contentType != "application/xml" {
+		t.Fatalf("got content type %q", contentType)
+	}
+
+	pet, err := DecodeCreatePetResponse(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if pet.Name != "Rex" || pet.ID != 7 {
+		t.Errorf("got pet %+v", pet)
+	}
+}
+
+func TestNegotiation(t *testing.T) {
+	server := newContentServer()
+	defer server.Close()
+
+	for _, tc := range []struct {
+		accept      string
+		status      int
+		contentType string
+	}{
+		{"", http.StatusCreated, "application/json"},
+		{"application/xml;q=0.5, application/json", http.StatusCreated, "application/json"},
+		{"text/html, application/*;q=0.8", http.StatusCreated, "application/json"},
+		{"text/xml, application/xml;q=0.9", http.StatusCreated, "application/xml"},
+		{"text/html", http.StatusNotAcceptable, "text/plain; charset=utf-8"},
+	} {
+		req, err := http.NewRequest(http.MethodPost, server.URL+"/pets", strings.NewReader(` + "`" + `{"name":"Rex"}` + "`" + `))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if tc.accept != "" {
+			req.Header.Set("Accept", tc.accept)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = resp.Body.Close()
+
+		if resp.StatusCode != tc.status || resp.Header.Get("Content-Type") != tc.contentType {
+			t.Errorf("Accept %q: got status %d and %q", tc.accept, resp.StatusCode, resp.Header.Get("Content-Type"))
+		}
+	}
+
+	resp, err := http.Post(server.URL+"/pets", "text/plain", strings.NewReader("Rex"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode != http.StatusUnsupportedMediaType {
+		t.Errorf("undeclared content type: got status %d", resp.StatusCode)
+	}
+}
+
+func TestBinaryAndText(t *testing.T) {
+	server := newContentServer()
+	defer server.Close()
+
+	client := &Client{URLs: &URLBuilder{ServerURL: server.URL}}
+
+	resp, err := client.GetPetPhoto(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	photo, err := DecodeGetPetPhotoResponse(resp)
+	if err != nil || !bytes.Equal(photo, []byte{0x89, 'P', 'N', 'G'}) {
+		t.Errorf("got photo %v: %v", photo, err)
+	}
+
+	resp, err = client.GetGreeting(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	greeting, err := DecodeGetGreetingResponse(resp)
+	if err != nil || greeting != "hello" {
+		t.Errorf("got greeting %q: %v", greeting, err)
+	}
+
+	if _, err := EncodeCreatePetRequest("text/plain", Pet{}); err == nil {
+		t.Error("undeclared content type: expected an error")
+	}
+}
+
+func TestBodyPerMediaType(t *testing.T) {
+	server := newContentServer()
+	defer server.Close()
+
+	client := &Client{URLs: &URLBuilder{ServerURL: server.URL}, HTTPClient: &http.Client{Transport: acceptTransport("text/csv")}}
+
+	resp, err := client.ExportPet(context.Background(), 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if _, err := DecodeExportPetResponse(resp); err == nil {
+		t.Error("a CSV response decoded as a pet")
+	}
+
+	resp, err = client.ExportPet(context.Background(), 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	csv, err := DecodeExportPetResponseCsv(resp)
+	if err != nil || csv != "id,name\n7,Rex\n" {
+		t.Errorf("got CSV %q: %v", csv, err)
+	}
+
+	client.HTTPClient = &http.Client{Transport: acceptTransport("application/octet-stream")}
+
+	resp, err = client.ExportPet(context.Background(), 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := DecodeExportPetResponseOctetStreamReader(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if raw, err := io.ReadAll(body); err != nil || string(raw) != "Rex" {
+		t.Errorf("got raw export %q: %v", raw, err)
+	}
+}
+
+func TestBinaryReader(t *testing.T) {
+	server := newContentServer()
+	defer server.Close()
+
+	client := &Client{URLs: &URLBuilder{ServerURL: server.URL}}
+
+	resp, err := client.UploadPetPhoto(context.Background(), 1, "application/octet-stream", bytes.NewReader([]byte{0x89, 'P', 'N', 'G'}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("got status %d", resp.StatusCode)
+	}
+
+	resp, err = client.GetPetPhoto(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	photo, err := DecodeGetPetPhotoResponseReader(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer photo.Close()
+
+	if raw, err := io.ReadAll(photo); err != nil || !bytes.Equal(raw, []byte{0x89, 'P', 'N', 'G'}) {
+		t.Errorf("got photo %v: %v", raw, err)
+	}
+}
+`})
+}
+
+func TestSecurityMiddlewareRuntime(t *testing.T) {
+	beforeTest(t)
+
+	oasYaml := `
+openapi: 3.0.3
+info:
+  title: Secured
+  version: 1.0.0
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      security:
+        - api_key: []
+      responses:
+        '204':
+          description: No content
+components:
+  securitySchemes:
+    api_key:
+      type: apiKey
+      in: header
+      name: X-API-Key
+`
+
+	_, err := generateWithSpec(oasYaml)
+	require.NoError(t, err)
+
+	testGenerated(t, map[string]string{"security_test.go": `package openapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type keyAuthenticator string
+
+func (a keyAuthenticator) Authenticate(_ context.Context, credentials *AuthCredentials) error {
+	if credentials.APIKey != string(a) {
+		return errors.New("invalid key")
+	}
+
+	return nil
+}
+
+func TestSecurityMiddleware(t *testing.T) {
+	handler := SecurityMiddleware(keyAuthenticator("secret"))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	client := &http.Client{Transport: &AuthTransport{Editors: []RequestEditorFn{WithAPIKey("secret")}}}
+
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	resp, err := client.Get(server.URL + "/pets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("valid key: got status %d", resp.StatusCode)
+	}
+
+	for _, tc := range []struct {
+		path   string
+		key    string
+		status int
+	}{
+		{"/pets", "", http.StatusUnauthorized},
+		{"/pets", "wrong", http.StatusUnauthorized},
+		{"/unknown", "secret", http.StatusNotFound},
+	} {
+		req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		if tc.key != "" {
+			req.Header.Set("X-API-Key", tc.key)
+		}
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != tc.status {
+			t.Errorf("GET %s with key %q: got status %d, want %d", tc.path, tc.key, rec.Code, tc.status)
+		}
+	}
+}
+`})
+}
+
+func TestMultipartBody(t *testing.T) {
+	beforeTest(t)
+
+	oasYaml := `
+openapi: "3.0.0"
+info:
+  title: "Test"
+  version: "1.0.0"
+paths:
+  /avatars:
+    post:
+      operationId: uploadAvatar
+      requestBody:
+        content:
+          multipart/form-data:
+            schema:
+              type: object
+              required: [avatar]
+              properties:
+                avatar:
+                  type: string
+                  format: binary
+                tags:
+                  type: array
+                  items:
+                    type: string
+            encoding:
+              avatar:
+                contentType: image/png, image/jpeg
+                headers:
+                  X-Checksum:
+                    required: true
+                    schema:
+                      type: string
+      responses:
+        "204":
+          description: OK
+  /login:
+    post:
+      requestBody:
+        content:
+          application/x-www-form-urlencoded:
+            schema:
+              $ref: "#/components/schemas/Credentials"
+            encoding:
+              scopes:
+                style: pipeDelimited
+                explode: false
+      responses:
+        "204":
+          description: OK
+components:
+  schemas:
+    Credentials:
+      type: object
+      properties:
+        login:
+          type: string
+        scopes:
+          type: array
+          items:
+            type: string
+`
+
+	expectedBody := strings.TrimPrefix(`
+// Code generated by openapi3-go-gen. DO NOT EDIT.
+
+package openapi
+
+import (
+	"errors"
+)
+
+type UploadAvatarBody struct {
+	Avatar *FormFile `+"`json:\"avatar\"`"+`
+	Tags   []string  `+"`json:\"tags,omitempty\"`"+`
+}
+
+func (instance *UploadAvatarBody) Validate() error {
+	if instance.Avatar == nil {
+		return errors.New("Value for field Avatar must be present")
+	}
+	return nil
+}
+`, "\n")
+
+	expectedForms := []string{`
+var uploadAvatarForm = []formField{
+	{Name: "avatar", Field: "Avatar", ContentType: "image/png", Headers: []string{"X-Checksum"}, Style: "form", Explode: true},
+	{Name: "tags", Field: "Tags", ContentType: "", Headers: []string{}, Style: "form", Explode: true},
+}
+
+func EncodeUploadAvatarBody(w *multipart.Writer, body *UploadAvatarBody) error {
+	return encodeMultipart(w, body, uploadAvatarForm)
+}
+
+func DecodeUploadAvatarBody(r *http.Request) (*UploadAvatarBody, error) {
+`, `
+var postLoginForm = []formField{
+	{Name: "login", Field: "Login", ContentType: "", Headers: []string{}, Style: "form", Explode: true},
+	{Name: "scopes", Field: "Scopes", ContentType: "", Headers: []string{}, Style: "pipeDelimited", Explode: false},
+}
+
+func EncodePostLoginBody(body *Credentials) (url.Values, error) {
+	return encodeForm(body, postLoginForm)
+}
+
+func DecodePostLoginBody(r *http.Request) (*Credentials, error) {
+`}
+
+	_, err := generateWithSpec(oasYaml)
+	require.NoError(t, err)
+
+	body, err := readGoFile("upload_avatar_body.go")
+	require.NoError(t, err)
+	require.Equal(t, expectedBody, body)
+
+	forms, err := readGoFile("form.go")
+	require.NoError(t, err)
+
+	for _, expected := range expectedForms {
+		require.Contains(t, forms, expected)
+	}
+}
+
+func TestFormObjectFields(t *testing.T) {
+	beforeTest(t)
+
+	oasYaml := `
+openapi: 3.0.3
+info:
+  title: Forms
+  version: 1.0.0
+paths:
+  /files:
+    post:
+      operationId: uploadFile
+      requestBody:
+        content:
+          multipart/form-data:
+            schema:
+              type: object
+              properties:
+                content:
+                  type: string
+                  format: binary
+                meta:
+                  $ref: '#/components/schemas/File'
+      responses:
+        '204':
+          description: OK
+  /search:
+    post:
+      operationId: search
+      requestBody:
+        content:
+          application/x-www-form-urlencoded:
+            schema:
+              type: object
+              properties:
+                query:
+                  type: string
+                address:
+                  $ref: '#/components/schemas/Address'
+                filter:
+                  type: object
+                  properties:
+                    color:
+                      type: string
+                    size:
+                      type: integer
+                point:
+                  type: object
+                  properties:
+                    x:
+                      type: integer
+                    y:
+                      type: integer
+                labels:
+                  type: object
+                  additionalProperties:
+                    type: string
+            encoding:
+              filter:
+                style: deepObject
+                explode: true
+              point:
+                explode: false
+      responses:
+        '204':
+          description: OK
+components:
+  schemas:
+    File:
+      type: object
+      properties:
+        name:
+          type: string
+    Address:
+      type: object
+      properties:
+        city:
+          type: string
+        zip:
+          type: string
+`
+
+	_, err := generateWithSpec(oasYaml)
+	require.NoError(t, err)
+
+	forms, err := readGoFile("form.go")
+	require.NoError(t, err)
+	require.Contains(t, forms, "type FormFile struct {")
+	require.Contains(t, forms, `{Name: "filter", Field: "Filter", ContentType: "application/json", Headers: []string{}, Style: "deepObject", Explode: true, Object: true, Properties: []formProperty{{Name: "color", Field: "Color"}, {Name: "size", Field: "Size"}}},`)
+
+	testGenerated(t, map[string]string{"form_test.go": `package openapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSearchBody(t *testing.T) {
+	body := &SearchBody{
+		Query:   "shoes",
+		Address: Address{City: "Paris"},
+		Filter:  SearchBodyFilter{Color: "red", Size: 2},
+		Point:   SearchBodyPoint{X: 1, Y: 2},
+		Labels:  map[string]interface{}{"brand": "acme"},
+	}
+
+	values, err := EncodeSearchBody(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "brand=acme&city=Paris&filter%5Bcolor%5D=red&filter%5Bsize%5D=2&point=x%2C1%2Cy%2C2&query=shoes&zip="
+	if encoded := values.Encode(); encoded != expected {
+		t.Fatalf("got %s, want %s", encoded, expected)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/search", strings.NewReader(values.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	decoded, err := DecodeSearchBody(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(decoded, body) {
+		t.Errorf("got %+v", decoded)
+	}
+}
+
+func TestFileModel(t *testing.T) {
+	body := &UploadFileBody{Content: &FormFile{Filename: "a.txt", Content: []byte("a")}, Meta: File{Name: "a"}}
+	if err := body.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+`})
+}
+
+func TestFormVariants(t *testing.T) {
+	beforeTest(t)
+
+	oasYaml := `
+openapi: 3.0.3
+info:
+  title: Forms
+  version: 1.0.0
+paths:
+  /notes:
+    post:
+      operationId: createNote
+      requestBody:
+        content:
+          multipart/form-data:
+            schema:
+              type: object
+              properties:
+                text:
+                  type: string
+                attachment:
+                  type: string
+                  format: binary
+          application/x-www-form-urlencoded; charset=utf-8:
+            schema:
+              $ref: '#/components/schemas/Note'
+      responses:
+        '204':
+          description: OK
+components:
+  schemas:
+    Note:
+      type: object
+      properties:
+        text:
+          type: string
+`
+
+	_, err := generateWithSpec(oasYaml)
+	require.NoError(t, err)
+
+	forms, err := readGoFile("form.go")
+	require.NoError(t, err)
+	require.Contains(t, forms, "func EncodeCreateNoteBody(w *multipart.Writer, body *CreateNoteBody) error {")
+	require.Contains(t, forms, "func EncodeCreateNoteURLEncodedBody(body *Note) (url.Values, error) {")
+
+	testGenerated(t, map[string]string{"form_test.go": `package openapi
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFormVariants(t *testing.T) {
+	var buf bytes.Buffer
+
+	w := multipart.NewWriter(&buf)
+	if err := EncodeCreateNoteBody(w, &CreateNoteBody{Text: "multipart"}); err != nil {
+		t.Fatal(err)
+	}
+	_ = w.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/notes", &buf)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+
+	multipartBody, err := DecodeCreateNoteBody(req)
+	if err != nil || multipartBody.Text != "multipart" {
+		t.Errorf("got multipart body %+v: %v", multipartBody, err)
+	}
+
+	values, err := EncodeCreateNoteURLEncodedBody(&Note{Text: "form"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/notes", strings.NewReader(values.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+
+	formBody, err := DecodeCreateNoteURLEncodedBody(req)
+	if err != nil || formBody.Text != "form" {
+		t.Errorf("got form body %+v: %v", formBody, err)
+	}
+}
+`})
+}
+
+func TestFormHeadersOnValueParts(t *testing.T) {
+	beforeTest(t)
+
+	oasYaml := `
+openapi: 3.0.3
+info:
+  title: Forms
+  version: 1.0.0
+paths:
+  /notes:
+    post:
+      operationId: createNote
+      requestBody:
+        content:
+          multipart/form-data:
+            schema:
+              type: object
+              properties:
+                text:
+                  type: string
+            encoding:
+              text:
+                headers:
+                  X-Checksum:
+                    required: true
+                    schema:
+                      type: string
+      responses:
+        '204':
+          description: OK
+`
+
+	doc, err := generateWithSpec(oasYaml)
+	require.NoError(t, err)
+
+	naming := generator.Naming{}
+	models := generator.NewSchemaResolver(doc, generator.NewFlattener(doc, naming).Flatten(), naming).Resolve()
+
+	formResolver := generator.NewFormResolver(doc, models, naming)
+	_, err = formResolver.Resolve()
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"required encoding headers X-Checksum of part text of operation CreateNote are ignored, only binary parts carry headers",
+	}, formResolver.Warnings())
+
+	formGo, err := readGoFile("form.go")
+	require.NoError(t, err)
+	require.Contains(t, formGo, `{Name: "text", Field: "Text", ContentType: "", Headers: []string{}, Style: "form", Explode: true},`)
+
+	testGenerated(t, map[string]string{"form_test.go": `package openapi
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFormHeadersIgnored(t *testing.T) {
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	if err := EncodeCreateNoteBody(w, &CreateNoteBody{Text: "note"}); err != nil {
+		t.Fatal(err)
+	}
+	_ = w.Close()
+
+	r := httptest.NewRequest("POST", "/notes", &buf)
+	r.Header.Set("Content-Type", w.FormDataContentType())
+
+	body, err := DecodeCreateNoteBody(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if body.Text != "note" {
+		t.Fatalf("unexpected text %q", body.Text)
+	}
+}
+`})
+}
+
+func TestXML(t *testing.T) {
+	beforeTest(t)
+
+	schemasYaml := `
+Foo:
+  type: object
+  xml:
+    name: foo
+    namespace: https://example.com/schema
+  properties:
+    id:
+      type: integer
+      xml:
+        attribute: true
+    name:
+      type: string
+      xml:
+        name: full-name
+    tags:
+      type: array
+      xml:
+        wrapped: true
+      items:
+        type: string
+        xml:
+          name: tag
+    aliases:
+      type: array
+      items:
+        type: string
+`
+
+	expectedFoo := strings.TrimPrefix(`
+// Code generated by openapi3-go-gen. DO NOT EDIT.
+
+package openapi
+
+import "encoding/xml"
+
+type Foo struct {
+	XMLName xml.Name `+"`"+`xml:"https://example.com/schema foo" json:"-"`+"`"+`
+	ID      int      `+"`"+`json:"id,omitempty" xml:"id,attr"`+"`"+`
+	Name    string   `+"`"+`json:"name,omitempty" xml:"full-name"`+"`"+`
+	Tags    []string `+"`"+`json:"tags,omitempty" xml:"tags>tag"`+"`"+`
+	Aliases []string `+"`"+`json:"aliases,omitempty" xml:"aliases"`+"`"+`
+}
+
+func (instance *Foo) Validate() error {
+	return nil
+}
+`, "\n")
+
+	err := generate(schemasYaml)
+	require.NoError(t, err)
+
+	foo, err := readGoFile("foo.go")
+	require.NoError(t, err)
+
+	require.Equal(t, expectedFoo, foo)
+
+	testGenerated(t, map[string]string{"xml_test.go": `package openapi
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFooRoundTrip(t *testing.T) {
+	foo := Foo{ID: 1, Name: "foo", Tags: []string{"a"}}
+
+	encoded, err := json.Marshal(foo)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(string(encoded), "XMLName") {
+		t.Fatalf("XMLName is encoded into %s", encoded)
+	}
+
+	var decoded Foo
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(decoded, foo) {
+		t.Fatalf("got %+v, want %+v", decoded, foo)
+	}
+
+	encoded, err = xml.Marshal(foo)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded = Foo{}
+	if err := xml.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	if decoded.Name != foo.Name || decoded.XMLName.Local != "foo" {
+		t.Fatalf("got %+v from %s", decoded, encoded)
+	}
+}
+`})
+}
+
+const streamsOasYaml = `
+openapi: "3.0.0"
+info:
+  title: "Test"
+  version: "1.0.0"
+paths:
+  /events:
+    get:
+      operationId: listenEvents
+      responses:
+        "200":
+          description: OK
+          content:
+            text/event-stream:
+              schema:
+                type: object
+                required: [kind]
+                properties:
+                  kind:
+                    type: string
+              example:
+                - kind: created
+                  note: kept as given
+                - kind: deleted
+  /logs:
+    get:
+      operationId: tailLogs
+      responses:
+        "200":
+          description: OK
+          content:
+            application/x-ndjson:
+              schema:
+                type: string
+              examples:
+                single:
+                  value: only
+`
+
+func TestStreams(t *testing.T) {
+	beforeTest(t)
+
+	expectedItem := strings.TrimPrefix(`
+// Code generated by openapi3-go-gen. DO NOT EDIT.
+
+package openapi
+
+import (
+	"errors"
+)
+
+type ListenEventsItem struct {
+	Kind string `+"`json:\"kind\"`"+`
+}
+
+func (instance *ListenEventsItem) Validate() error {
+	if instance.Kind == "" {
+		return errors.New("Value for field Kind must be not empty")
+	}
+	return nil
+}
+`, "\n")
+
+	expectedStreams := []string{`
+func (s *ListenEventsStreamReader) Next() (ListenEventsItem, error) {
+	var item ListenEventsItem
+
+	data, err := readEventData(s.reader)
+	if err != nil {
+		return item, err
+	}
+`, `
+func (s *ListenEventsStreamWriter) Send(item ListenEventsItem) error {
+	if err := item.Validate(); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+
+	return writeStreamChunk(s.w, []byte("data: "), data, []byte("\n\n"))
+}
+`, `
+func (s *TailLogsStreamWriter) Send(item string) error {
+	data, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+
+	return writeStreamChunk(s.w, data, []byte("\n"))
+}
+`}
+
+	_, err := generateWithSpec(streamsOasYaml)
+	require.NoError(t, err)
+
+	item, err := readGoFile("listen_events_item.go")
+	require.NoError(t, err)
+	require.Equal(t, expectedItem, item)
+
+	streams, err := readGoFile("stream.go")
+	require.NoError(t, err)
+
+	for _, expected := range expectedStreams {
+		require.Contains(t, streams, expected)
+	}
+}
+
+func TestStreamVariantsRuntime(t *testing.T) {
+	beforeTest(t)
+
+	oasYaml := `
+openapi: "3.0.0"
+info:
+  title: "Test"
+  version: "1.0.0"
+paths:
+  /feed:
+    get:
+      operationId: watchFeed
+      responses:
+        "200":
+          description: OK
+          content:
+            text/event-stream; charset=utf-8:
+              schema:
+                $ref: '#/components/schemas/Entry'
+            application/x-ndjson:
+              schema:
+                $ref: '#/components/schemas/Entry'
+components:
+  schemas:
+    Entry:
+      type: object
+      properties:
+        title:
+          type: string
+`
+
+	expectedStreams := []string{`
+func (s *WatchFeedStreamReader) Next() (Entry, error) {
+	var item Entry
+
+	data, err := readEventData(s.reader)`, `
+func NewWatchFeedStreamWriter(w http.ResponseWriter) *WatchFeedStreamWriter {
+	w.Header().Set("Content-Type", "text/event-stream; charset=utf-8")`, `
+func (s *WatchFeedNDJSONStreamReader) Next() (Entry, error) {
+	var item Entry
+
+	data, err := readNDJSONLine(s.reader)`, `
+func NewWatchFeedNDJSONStreamWriter(w http.ResponseWriter) *WatchFeedNDJSONStreamWriter {
+	w.Header().Set("Content-Type", "application/x-ndjson")`}
+
+	_, err := generateWithSpec(oasYaml)
+	require.NoError(t, err)
+
+	streams, err := readGoFile("stream.go")
+	require.NoError(t, err)
+
+	for _, expected := range expectedStreams {
+		require.Contains(t, streams, expected)
+	}
+
+	testGenerated(t, map[string]string{"stream_test.go": `package openapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestStreamVariants(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var err error
+
+		if r.Header.Get("Accept") == "application/x-ndjson" {
+			err = NewWatchFeedNDJSONStreamWriter(w).Send(Entry{Title: "line"})
+		} else {
+			err = NewWatchFeedStreamWriter(w).Send(Entry{Title: "event"})
+		}
+
+		if err != nil {
+			t.Error(err)
+		}
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: &ValidatingTransport{Options: ResponseValidationOptions{Mode: ResponseValidationFail}}}
+
+	resp, err := client.Get(server.URL + "/feed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	event, err := NewWatchFeedStreamReader(resp.Body).Next()
+	if err != nil || event.Title != "event" {
+		t.Errorf("got event %+v: %v", event, err)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/feed", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Accept", "application/x-ndjson")
+
+	resp, err = client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	line, err := NewWatchFeedNDJSONStreamReader(resp.Body).Next()
+	if err != nil || line.Title != "line" {
+		t.Errorf("got line %+v: %v", line, err)
+	}
+}
+`})
+}
+
+func TestStreamsBehindResponseValidationRuntime(t *testing.T) {
+	beforeTest(t)
+
+	_, err := generateWithSpec(streamsOasYaml)
+	require.NoError(t, err)
+
+	testGenerated(t, map[string]string{"stream_test.go": `package openapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestStreamBehindResponseValidation(t *testing.T) {
+	received := make(chan struct{})
+
+	handler := ResponseValidationMiddleware(ResponseValidationOptions{Mode: ResponseValidationFail})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			writer := NewListenEventsStreamWriter(w)
+
+			if err := writer.Send(ListenEventsItem{Kind: "first"}); err != nil {
+				t.Error(err)
+				return
+			}
+
+			select {
+			case <-received:
+			case <-time.After(5 * time.Second):
+				t.Error("the first event was not flushed to the client")
+				return
+			}
+
+			if err := writer.Send(ListenEventsItem{Kind: "second"}); err != nil {
+				t.Error(err)
+			}
+		}),
+	)
+
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	reader := NewListenEventsStreamReader(resp.Body)
+
+	for _, kind := range []string{"first", "second"} {
+		item, err := reader.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if item.Kind != kind {
+			t.Errorf("got event %+v, want %s", item, kind)
+		}
+
+		if kind == "first" {
+			close(received)
+		}
+	}
+}
+
+func TestStreamThroughValidatingTransport(t *testing.T) {
+	received := make(chan struct{})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writer := NewListenEventsStreamWriter(w)
+
+		if err := writer.Send(ListenEventsItem{Kind: "first"}); err != nil {
+			t.Error(err)
+			return
+		}
+
+		select {
+		case <-received:
+		case <-time.After(5 * time.Second):
+			t.Error("the first event was not read before the stream ended")
+			return
+		}
+
+		if err := writer.Send(ListenEventsItem{Kind: "second"}); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: &ValidatingTransport{Options: ResponseValidationOptions{Mode: ResponseValidationFail}}}
+
+	resp, err := client.Get(server.URL + "/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	reader := NewListenEventsStreamReader(resp.Body)
+
+	for _, kind := range []string{"first", "second"} {
+		item, err := reader.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if item.Kind != kind {
+			t.Errorf("got event %+v, want %s", item, kind)
+		}
+
+		if kind == "first" {
+			close(received)
+		}
+	}
+}
+
+func TestRejectedStream(t *testing.T) {
+	handler := ResponseValidationMiddleware(ResponseValidationOptions{Mode: ResponseValidationFail})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			writer := NewTailLogsStreamWriter(w)
+			w.WriteHeader(http.StatusTeapot)
+
+			if err := writer.Send("line"); err == nil {
+				t.Error("expected the rejected stream to fail")
+			}
+		}),
+	)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/logs", nil))
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("got status %d: %s", rec.Code, rec.Body)
+	}
+}
+`})
+}
+
+func TestMockStreamsRuntime(t *testing.T) {
+	beforeTest(t)
+
+	_, err := generateWithSpec(streamsOasYaml)
+	require.NoError(t, err)
+
+	mock, err := readGoFile("mock.go")
+	require.NoError(t, err)
+	require.Contains(t, mock, `defaultExample: "data: {\"kind\":\"created\",\"note\":\"kept as given\"}\n\ndata: {\"kind\":\"deleted\"}\n\n",`)
+	require.Contains(t, mock, `"single": "\"only\"\n",`)
+
+	testGenerated(t, map[string]string{"mock_stream_test.go": `package openapi
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMockStreams(t *testing.T) {
+	server := httptest.NewServer(NewMockServer())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if contentType := resp.Header.Get("Content-Type"); contentType != "text/event-stream" {
+		t.Errorf("got content type %q", contentType)
+	}
+
+	events := NewListenEventsStreamReader(resp.Body)
+
+	for _, kind := range []string{"created", "deleted"} {
+		item, err := events.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if item.Kind != kind {
+			t.Errorf("got event %+v, want %s", item, kind)
+		}
+	}
+
+	if _, err := events.Next(); err != io.EOF {
+		t.Errorf("expected the end of the stream, got %v", err)
+	}
+
+	resp, err = http.Get(server.URL + "/logs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	line, err := NewTailLogsStreamReader(resp.Body).Next()
+	if err != nil || line != "only" {
+		t.Errorf("got line %q: %v", line, err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/logs", nil)
+	req.Header.Set(MockExampleHeader, "missing")
+
+	rec := httptest.NewRecorder()
+	NewMockServer().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("missing example: got status %d", rec.Code)
+	}
+}
+`})
+}
+
+func TestCallbacks(t *testing.T) {
+	beforeTest(t)
+
+	oasYaml := `
+openapi: "3.0.0"
+info:
+  title: "Test"
+  version: "1.0.0"
+paths:
+  /subscriptions:
+    post:
+      operationId: subscribe
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object
+              properties:
+                callbackUrl:
+                  type: string
+      responses:
+        "201":
+          description: Created
+      callbacks:
+        onEvent:
+          "{$request.body#/callbackUrl}/events":
+            post:
+              requestBody:
+                content:
+                  application/json:
+                    schema:
+                      type: object
+                      required: [message]
+                      properties:
+                        message:
+                          type: string
+              responses:
+                "204":
+                  description: Received
+`
+
+	expectedBody := strings.TrimPrefix(`
+// Code generated by openapi3-go-gen. DO NOT EDIT.
+
+package openapi
+
+import (
+	"errors"
+)
+
+type SubscribeOnEventBody struct {
+	Message string `+"`json:\"message\"`"+`
+}
+
+func (instance *SubscribeOnEventBody) Validate() error {
+	if instance.Message == "" {
+		return errors.New("Value for field Message must be not empty")
+	}
+	return nil
+}
+`, "\n")
+
+	expectedCallbacks := []string{`
+func ResolveSubscribeOnEventURL(req *http.Request, body []byte, pathParams map[string]string) (string, error) {
+	return resolveRuntimeExpressions("{$request.body#/callbackUrl}/events", req, body, pathParams)
+}
+`, `
+func SendSubscribeOnEvent(ctx context.Context, client HTTPRequestDoer, callbackURL string, body *SubscribeOnEventBody) (*http.Response, error) {
+	if body == nil {
+		return nil, fmt.Errorf("callback body is required")
+	}
+`, `
+	req, err := http.NewRequestWithContext(ctx, "POST", callbackURL, reader)
+`, `
+type SubscribeOnEventReceiver interface {
+	HandleSubscribeOnEvent(ctx context.Context, body *SubscribeOnEventBody) error
+}
+`, `
+		w.WriteHeader(204)
+`}
+
+	_, err := generateWithSpec(oasYaml)
+	require.NoError(t, err)
+
+	body, err := readGoFile("subscribe_on_event_body.go")
+	require.NoError(t, err)
+	require.Equal(t, expectedBody, body)
+
+	callbacks, err := readGoFile("callback.go")
+	require.NoError(t, err)
+
+	for _, expected := range expectedCallbacks {
+		require.Contains(t, callbacks, expected)
+	}
+
+	testGenerated(t, map[string]string{"callback_test.go": `package openapi
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type recordingDoer struct {
+	requests []*http.Request
+}
+
+func (d *recordingDoer) Do(req *http.Request) (*http.Response, error) {
+	d.requests = append(d.requests, req)
+
+	return &http.Response{StatusCode: http.StatusNoContent, Body: io.NopCloser(strings.NewReader(""))}, nil
+}
+
+func TestSendThroughRequestDoer(t *testing.T) {
+	doer := &recordingDoer{}
+
+	resp, err := SendSubscribeOnEvent(context.Background(), doer, "http://example.com/events", &SubscribeOnEventBody{Message: "hi"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+
+	if len(doer.requests) != 1 || doer.requests[0].URL.String() != "http://example.com/events" {
+		t.Errorf("got requests %v", doer.requests)
+	}
+}
+`})
+}
+
+func TestURLBuilders(t *testing.T) {
+	beforeTest(t)
+
+	oasYaml := `
+openapi: "3.0.0"
+info:
+  title: "Test"
+  version: "1.0.0"
+servers:
+  - url: "https://{region}.example.com/v1"
+    variables:
+      region:
+        default: eu
+paths:
+  /pets/{petId}:
+    parameters:
+      - name: petId
+        in: path
+        required: true
+        schema:
+          type: string
+    get:
+      operationId: getPet
+      parameters:
+        - name: fields
+          in: query
+          style: form
+          explode: false
+          schema:
+            type: array
+            items:
+              type: string
+        - name: limit
+          in: query
+          schema:
+            type: integer
+      responses:
+        "200":
+          description: OK
+  /pets:
+    get:
+      operationId: listPets
+      parameters:
+        - name: filter
+          in: query
+          style: deepObject
+          schema:
+            type: object
+            required: [color]
+            properties:
+              color:
+                type: string
+              size:
+                type: integer
+        - name: labels
+          in: query
+          schema:
+            type: object
+            additionalProperties:
+              type: string
+      responses:
+        "200":
+          description: OK
+`
+
+	expectedURLs := []string{`
+func NewURLBuilder() *URLBuilder {
+	return &URLBuilder{
+		ServerURL: "https://{region}.example.com/v1",
+		Variables: map[string]string{
+			"region": "eu",
+		},
+	}
+}
+`, `
+type GetPetURLParams struct {
+	PetID  string
+	Fields []string
+	Limit  *int
+}
+
+func (b *URLBuilder) GetPetURL(params GetPetURLParams) (*url.URL, error) {
+	path := "/pets/{petId}"
+	query := make([]string, 0)
+	path = strings.Replace(path, "{petId}", formatPathParam("petId", params.PetID, "simple", false), 1)
+	query = appendQueryParam(query, "fields", params.Fields, "form", false)
+	query = appendQueryParam(query, "limit", params.Limit, "form", true)
+
+	return b.buildOperationURL(path, query)
+}
+`, `
+type ListPetsFilterParam struct {
+	Color string
+	Size  *int
+}
+
+func (p ListPetsFilterParam) urlPairs() ([]string, []string) {
+	keys := make([]string, 0, 2)
+	values := make([]string, 0, 2)
+
+	keys = append(keys, "color")
+	values = append(values, formatURLValue(reflect.ValueOf(p.Color)))
+
+	if p.Size != nil {
+		keys = append(keys, "size")
+		values = append(values, formatURLValue(reflect.ValueOf(p.Size)))
+	}
+
+	return keys, values
+}
+`, `
+type ListPetsURLParams struct {
+	Filter *ListPetsFilterParam
+	Labels map[string]string
+}
+`}
+
+	_, err := generateWithSpec(oasYaml)
+	require.NoError(t, err)
+
+	urls, err := readGoFile("url.go")
+	require.NoError(t, err)
+
+	for _, expected := range expectedURLs {
+		require.Contains(t, urls, expected)
+	}
+
+	testGenerated(t, map[string]string{"url_test.go": `package openapi
+
+import "testing"
+
+func TestURLBuilder(t *testing.T) {
+	urls := NewURLBuilder()
+	urls.Variables["region"] = "us"
+
+	size := 2
+
+	for _, tc := range []struct {
+		params ListPetsURLParams
+		want   string
+	}{
+		{ListPetsURLParams{}, "https://us.example.com/v1/pets"},
+		{ListPetsURLParams{Filter: &ListPetsFilterParam{Color: "red", Size: &size}}, "https://us.example.com/v1/pets?filter%5Bcolor%5D=red&filter%5Bsize%5D=2"},
+		{ListPetsURLParams{Labels: map[string]string{"b": "2", "a": "1"}}, "https://us.example.com/v1/pets?a=1&b=2"},
+	} {
+		got, err := urls.ListPetsURL(tc.params)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got.String() != tc.want {
+			t.Errorf("got %s, want %s", got, tc.want)
+		}
+	}
+
+	if NewURLBuilder().Variables["region"] != "eu" {
+		t.Error("builders share their variables")
+	}
+}
+`})
+}
+
+func TestURLParamRaw(t *testing.T) {
+	beforeTest(t)
+
+	oasYaml := `
+openapi: "3.0.0"
+info:
+  title: "Test"
+  version: "1.0.0"
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      parameters:
+        - name: filter
+          in: query
+          schema:
+            type: object
+            properties:
+              owner:
+                type: object
+                properties:
+                  name:
+                    type: string
+        - name: kind
+          in: query
+          required: true
+          schema:
+            oneOf:
+              - type: string
+              - type: integer
+        - name: tags
+          in: query
+          schema:
+            type: array
+            items:
+              type: object
+      responses:
+        "200":
+          description: OK
+`
+
+	doc, err := generateWithSpec(oasYaml)
+	require.NoError(t, err)
+
+	urlResolver := generator.NewURLResolver(doc, generator.Naming{})
+	_, err = urlResolver.Resolve()
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"query param filter of operation ListPets is passed as a raw string: property owner of an object param must be a scalar",
+		"query param kind of operation ListPets is passed as a raw string: oneOf and anyOf schemas can't be serialized into a URL",
+		"query param tags of operation ListPets is passed as a raw string: only arrays of scalars can be serialized into a URL",
+	}, urlResolver.Warnings())
+
+	urlGo, err := readGoFile("url.go")
+	require.NoError(t, err)
+	require.Contains(t, urlGo, `
+type ListPetsURLParams struct {
+	Filter *string
+	Kind   string
+	Tags   *string
+}`)
+
+	testGenerated(t, map[string]string{"url_test.go": urlRawTestGo})
+}
+
+const urlRawTestGo = `package openapi
+
+import "testing"
+
+func TestRawParams(t *testing.T) {
+	filter := "owner"
+
+	u, err := NewURLBuilder().ListPetsURL(ListPetsURLParams{Filter: &filter, Kind: "cat&dog"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if u.RequestURI() != "/pets?filter=owner&kind=cat%26dog" {
+		t.Fatalf("unexpected URL %s", u.RequestURI())
+	}
+}
+`
+
+const serverOasYaml = `
+openapi: 3.0.3
+info:
+  title: Pets
+  version: 1.0.0
+paths:
+  /pets:
+    post:
+      operationId: createPet
+      responses:
+        '201':
+          description: Created
+  /pets/{petId}:
+    parameters:
+      - name: petId
+        in: path
+        required: true
+        schema:
+          type: integer
+    get:
+      operationId: getPet
+      parameters:
+        - name: fields
+          in: query
+          explode: false
+          schema:
+            type: array
+            items:
+              type: string
+        - name: filter
+          in: query
+          style: deepObject
+          schema:
+            type: object
+            required: [color]
+            properties:
+              color:
+                type: string
+              size:
+                type: integer
+        - name: X-Request-ID
+          in: header
+          required: true
+          schema:
+            type: string
+        - name: session
+          in: cookie
+          schema:
+            type: string
+      responses:
+        '204':
+          description: No content
+`
+
+const serverTestGo = `package openapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+type petServer struct {
+	petID  int
+	params GetPetParams
+}
+
+func (s *petServer) CreatePet(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (s *petServer) GetPet(w http.ResponseWriter, r *http.Request, petID int, params GetPetParams) {
+	s.petID = petID
+	s.params = params
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func header(name string) MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("X-Middleware", name)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func TestServer(t *testing.T) {
+	server := &petServer{}
+	handler := newHandler(server, ServerOptions{
+		Middlewares:      []MiddlewareFunc{header("global")},
+		RouteMiddlewares: map[string][]MiddlewareFunc{"GetPet": {header("route")}},
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/pets/7?fields=name,tags&filter[color]=red&filter[size]=2", nil)
+	req.Header.Set("X-Request-ID", "abc")
+	req.AddCookie(&http.Cookie{Name: "session", Value: "s1"})
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("got status %d: %s", rec.Code, rec.Body)
+	}
+
+	if middlewares := rec.Header().Values("X-Middleware"); !reflect.DeepEqual(middlewares, []string{"global", "route"}) {
+		t.Errorf("got middlewares %v", middlewares)
+	}
+
+	session := "s1"
+	size := 2
+	expected := GetPetParams{
+		Fields:      []string{"name", "tags"},
+		Filter:      &GetPetFilterParam{Color: "red", Size: &size},
+		XRequestID:  "abc",
+		Session:     &session,
+	}
+
+	if server.petID != 7 || !reflect.DeepEqual(server.params, expected) {
+		t.Errorf("got pet %d and %+v", server.petID, server.params)
+	}
+
+	for _, tc := range []struct {
+		method     string
+		path       string
+		requestID  string
+		status     int
+		middleware []string
+	}{
+		{http.MethodGet, "/pets/abc", "abc", http.StatusBadRequest, []string{"global", "route"}},
+		{http.MethodGet, "/pets/7", "", http.StatusBadRequest, []string{"global", "route"}},
+		{http.MethodPost, "/pets", "", http.StatusCreated, []string{"global"}},
+		{http.MethodGet, "/unknown", "", http.StatusNotFound, nil},
+	} {
+		req := httptest.NewRequest(tc.method, tc.path, nil)
+		if tc.requestID != "" {
+			req.Header.Set("X-Request-ID", tc.requestID)
+		}
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != tc.status {
+			t.Errorf("%s %s: got status %d, want %d", tc.method, tc.path, rec.Code, tc.status)
+		}
+
+		if middlewares := rec.Header().Values("X-Middleware"); !reflect.DeepEqual(middlewares, tc.middleware) {
+			t.Errorf("%s %s: got middlewares %v", tc.method, tc.path, middlewares)
+		}
+	}
+}
+`
+
+func TestServerInterface(t *testing.T) {
+	beforeTest(t)
+
+	expectedServer := []string{`
+type ServerInterface interface {
+	// POST /pets
+	CreatePet(w http.ResponseWriter, r *http.Request)
+	// GET /pets/{petId}
+	GetPet(w http.ResponseWriter, r *http.Request, petID int, params GetPetParams)
+}
+`, `
+type GetPetParams struct {
+	Fields     []string
+	Filter     *GetPetFilterParam
+	XRequestID string
+	Session    *string
+}
+`}
+
+	_, err := generateWithSpec(serverOasYaml)
+	require.NoError(t, err)
+
+	server, err := readGoFile("server.go")
+	require.NoError(t, err)
+
+	for _, expected := range expectedServer {
+		require.Contains(t, server, expected)
+	}
+
+	_, err = readGoFile("router.go")
+	require.True(t, os.IsNotExist(err))
+
+	_, err = generateWithOptions(serverOasYaml, generator.NamingStrategyFail, "fasthttp")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "unknown router fasthttp, use one of chi, echo, gin")
+}
+
+func TestUnknownRouterWritesNothing(t *testing.T) {
+	beforeTest(t)
+
+	require.NoError(t, os.WriteFile("oas.yml", []byte(serverOasYaml), 0777))
+
+	err := app.Run([]string{"oas.yml"}, "gen", app.Options{EmbedSpec: true, Router: "fasthttp"})
+	require.EqualError(t, err, "unknown router fasthttp, use one of chi, echo, gin")
+
+	entries, err := os.ReadDir("gen")
+	require.NoError(t, err)
+	require.Empty(t, entries)
+}
+
+func TestFailingResolverWritesNothing(t *testing.T) {
+	beforeTest(t)
+
+	oasYaml := `
+openapi: 3.0.3
+info:
+  title: Pets
+  version: 1.0.0
+paths:
+  /pets/search:
+    post:
+      operationId: searchPets
+      x-pagination:
+        itemsField: items
+        offsetParam: offset
+      parameters:
+        - name: offset
+          in: query
+          schema:
+            type: integer
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object
+              properties:
+                name:
+                  type: string
+      responses:
+        '200':
+          description: OK
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/PetPage'
+components:
+  schemas:
+    PetPage:
+      type: object
+      properties:
+        items:
+          type: array
+          items:
+            type: string
+`
+
+	require.NoError(t, os.WriteFile("oas.yml", []byte(oasYaml), 0777))
+
+	t.Setenv("CODEGEN_TEMPLATES_FOLDER", "../pkg/generator/templates")
+
+	err := app.Run([]string{"oas.yml"}, "gen", app.Options{EmbedSpec: true, MockServer: true})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "failed while resolving pagination")
+
+	entries, err := os.ReadDir("gen")
+	require.NoError(t, err)
+	require.Empty(t, entries)
+}
+
+func TestServerRouters(t *testing.T) {
+	routers := []struct {
+		name    string
+		module  string
+		handler string
+	}{
+		{"chi", "github.com/go-chi/chi/v5 v5.0.8", `package openapi
+
+import "net/http"
+
+func newHandler(si ServerInterface, options ServerOptions) http.Handler {
+	return HandlerWithOptions(si, options)
+}
+`},
+		{"echo", "github.com/labstack/echo/v4 v4.9.1", `package openapi
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+func newHandler(si ServerInterface, options ServerOptions) http.Handler {
+	e := echo.New()
+	RegisterHandlersWithOptions(e, si, options)
+
+	return e
+}
+`},
+		{"gin", "github.com/gin-gonic/gin v1.9.1", `package openapi
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newHandler(si ServerInterface, options ServerOptions) http.Handler {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	RegisterHandlersWithOptions(r, si, options)
+
+	return r
+}
+`},
+	}
+
+	for _, router := range routers {
+		t.Run(router.name, func(t *testing.T) {
+			beforeTest(t)
+
+			_, err := generateWithOptions(serverOasYaml, generator.NamingStrategyFail, router.name)
+			require.NoError(t, err)
+
+			testGenerated(t, map[string]string{
+				"server_test.go":  serverTestGo,
+				"handler_test.go": router.handler,
+			}, router.module)
+		})
+	}
+}
+
+func TestMockServer(t *testing.T) {
+	beforeTest(t)
+
+	oasYaml := `
+openapi: "3.0.0"
+info:
+  title: "Test"
+  version: "1.0.0"
+servers:
+  - url: "https://api.example.com/v1"
+paths:
+  /pets/{petId}:
+    get:
+      operationId: getPet
+      parameters:
+        - name: petId
+          in: path
+          required: true
+          schema:
+            type: string
+      responses:
+        "200":
+          description: OK
+          content:
+            application/json:
+              schema:
+                type: object
+                properties:
+                  name:
+                    type: string
+                  born:
+                    type: string
+                    format: date
+              examples:
+                cat:
+                  value:
+                    name: Tom
+                dog:
+                  value:
+                    name: Rex
+  /pets:
+    post:
+      operationId: createPet
+      responses:
+        "201":
+          description: Created
+          content:
+            application/json:
+              schema:
+                type: object
+                properties:
+                  id:
+                    type: integer
+                    minimum: 1
+`
+
+	expectedMock := []string{`
+type MockServer struct {
+	CreatePetFunc func(w http.ResponseWriter, r *http.Request)
+	GetPetFunc    func(w http.ResponseWriter, r *http.Request, petID string)
+}
+
+var _ ServerInterface = (*MockServer)(nil)
+`, `
+func (s *MockServer) GetPet(w http.ResponseWriter, r *http.Request, petID string) {
+	if s.GetPetFunc != nil {
+		s.GetPetFunc(w, r, petID)
+		return
+	}
+
+	writeMockResponse(w, r, mockGetPetResponse)
+}
+`, `
+	{
+		method:  "GET",
+		path:    regexp.MustCompile("^(?:/v1)?/pets/([^/]+)$"),
+		params:  []string{"petId"},
+		handler: handleGetPet,
+	},
+`, `
+	mockCreatePetResponse = mockResponse{
+		statusCode:     201,
+		contentType:    "application/json",
+		defaultExample: "{\"id\":1}",
+	}
+	mockGetPetResponse = mockResponse{
+		statusCode:     200,
+		contentType:    "application/json",
+		defaultExample: "{\"name\":\"Tom\"}",
+		examples: map[string]string{
+			"cat": "{\"name\":\"Tom\"}",
+			"dog": "{\"name\":\"Rex\"}",
+		},
+	}
+`}
+
+	_, err := generateWithSpec(oasYaml)
+	require.NoError(t, err)
+
+	mock, err := readGoFile("mock.go")
+	require.NoError(t, err)
+
+	for _, expected := range expectedMock {
+		require.Contains(t, mock, expected)
+	}
+}
+
+func TestRecordingTransport(t *testing.T) {
+	beforeTest(t)
+
+	oasYaml := `
+openapi: "3.0.0"
+info:
+  title: "Test"
+  version: "1.0.0"
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      responses:
+        "200":
+          description: OK
+`
+
+	_, err := generateWithSpec(oasYaml)
+	require.NoError(t, err)
+
+	recording, err := readGoFile("recording.go")
+	require.NoError(t, err)
+	require.Contains(t, recording, "func (t *RecordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {")
+	require.Contains(t, recording, "func validateInteraction(req *http.Request, interaction *Interaction) error {")
+}
+
+func TestClientRecordingRuntime(t *testing.T) {
+	beforeTest(t)
+
+	oasYaml := `
+openapi: 3.0.3
+info:
+  title: Pets
+  version: 1.0.0
+servers:
+  - url: https://api.example.com/v1
+security:
+  - headerKey: []
+    queryKey: []
+    bearer: []
+paths:
+  /pets:
+    post:
+      operationId: createPet
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/Pet'
+      responses:
+        '201':
+          description: Created
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/Pet'
+  /pets/{id}:
+    get:
+      operationId: getPet
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema:
+            type: integer
+        - name: X-Trace
+          in: header
+          schema:
+            type: string
+      responses:
+        '200':
+          description: OK
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/Pet'
+components:
+  securitySchemes:
+    headerKey:
+      type: apiKey
+      in: header
+      name: X-Api-Key
+    queryKey:
+      type: apiKey
+      in: query
+      name: api_key
+    bearer:
+      type: http
+      scheme: bearer
+  schemas:
+    Pet:
+      type: object
+      required:
+        - name
+      properties:
+        id:
+          type: integer
+        name:
+          type: string
+`
+
+	expectedClient := []string{`
+type ClientInterface interface {
+	// POST /pets
+	CreatePet(ctx context.Context, contentType string, body io.Reader) (*http.Response, error)
+	// GET /pets/{id}
+	GetPet(ctx context.Context, id int, params GetPetParams) (*http.Response, error)
+}
+`, `
+	if value, ok := formatDelimitedParam(params.XTrace, false); ok {
+		req.Header.Set("X-Trace", value)
+	}
+`}
+
+	_, err := generateWithSpec(oasYaml)
+	require.NoError(t, err)
+
+	client, err := readGoFile("client.go")
+	require.NoError(t, err)
+
+	for _, expected := range expectedClient {
+		require.Contains(t, client, expected)
+	}
+
+	recording, err := readGoFile("recording.go")
+	require.NoError(t, err)
+	require.Contains(t, recording, `redactedHeaders     = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key"}`)
+	require.Contains(t, recording, `redactedQueryParams = []string{"api_key"}`)
+
+	testGenerated(t, map[string]string{"client_test.go": `package openapi
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newRecordingClient(serverURL string, mode RecordingMode, goldenFile string) *Client {
+	return &Client{
+		URLs: &URLBuilder{ServerURL: serverURL},
+		HTTPClient: &http.Client{
+			Transport: &AuthTransport{
+				Transport: &RecordingTransport{Mode: mode, GoldenFile: goldenFile},
+				Editors: []RequestEditorFn{
+					WithHeaderKey("secret-header-key"),
+					WithQueryKey("secret-query-key"),
+					WithBearer("secret-token"),
+				},
+			},
+		},
+	}
+}
+
+func readBody(t *testing.T, resp *http.Response) string {
+	t.Helper()
+
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(body)
+}
+
+func sendRequests(t *testing.T, client ClientInterface) {
+	t.Helper()
+
+	ctx := context.Background()
+	trace := "trace-1"
+
+	resp, err := client.GetPet(ctx, 1, GetPetParams{XTrace: &trace})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if body := readBody(t, resp); resp.StatusCode != http.StatusOK || body != ` + "`" + `{"id":1,"name":"Rex"}` + "`" + ` {
+		t.Fatalf("got %d %s", resp.StatusCode, body)
+	}
+
+	resp, err = client.CreatePet(ctx, "application/json", strings.NewReader(` + "`" + `{"name":"Rex"}` + "`" + `))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if body := readBody(t, resp); resp.StatusCode != http.StatusCreated || body != ` + "`" + `{"id":2,"name":"Rex"}` + "`" + ` {
+		t.Fatalf("got %d %s", resp.StatusCode, body)
+	}
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Api-Key") != "secret-header-key" || r.URL.Query().Get("api_key") != "secret-query-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		if r.Header.Get("X-Trace") != "trace-1" && r.Method == http.MethodGet {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "secret-session"})
+
+		if r.Method == http.MethodPost {
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(` + "`" + `{"id":2,"name":"Rex"}` + "`" + `))
+			return
+		}
+
+		_, _ = w.Write([]byte(` + "`" + `{"id":1,"name":"Rex"}` + "`" + `))
+	}))
+
+	goldenFile := filepath.Join(t.TempDir(), "golden.json")
+
+	sendRequests(t, newRecordingClient(server.URL, RecordingRecord, goldenFile))
+	server.Close()
+
+	golden, err := os.ReadFile(goldenFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(string(golden), "secret") {
+		t.Fatalf("credentials leaked into the golden file: %s", golden)
+	}
+
+	if !strings.Contains(string(golden), "REDACTED") {
+		t.Fatalf("credentials are not redacted: %s", golden)
+	}
+
+	sendRequests(t, newRecordingClient(server.URL, RecordingReplay, goldenFile))
+}
+
+func TestFakeClient(t *testing.T) {
+	fake := &FakeClient{
+		GetPetFunc: func(ctx context.Context, id int, params GetPetParams) (*http.Response, error) {
+			return &http.Response{StatusCode: http.StatusNoContent, Body: http.NoBody}, nil
+		},
+	}
+
+	var client ClientInterface = fake
+
+	resp, err := client.GetPet(context.Background(), 7, GetPetParams{})
+	if err != nil || resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("got %v, %v", resp, err)
+	}
+
+	if _, err := client.CreatePet(context.Background(), "application/json", strings.NewReader("{}")); err == nil {
+		t.Fatal("expected an error from an operation without a stub")
+	}
+
+	if fake.GetPetCallCount() != 1 || fake.GetPetCalls()[0].ID != 7 {
+		t.Errorf("got GetPet calls %+v", fake.GetPetCalls())
+	}
+
+	if calls := fake.CreatePetCalls(); len(calls) != 1 || calls[0].ContentType != "application/json" || string(calls[0].Body) != "{}" {
+		t.Errorf("got CreatePet calls %+v", calls)
+	}
+}
+`})
+}
+
+func TestProblemDetails(t *testing.T) {
+	beforeTest(t)
+
+	oasYaml := `
+openapi: "3.0.0"
+info:
+  title: "Test"
+  version: "1.0.0"
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      responses:
+        "200":
+          description: OK
+        default:
+          description: Error
+          content:
+            application/problem+json:
+              schema:
+                $ref: "#/components/schemas/Problem"
+components:
+  schemas:
+    Problem:
+      type: object
+      required: [title]
+      properties:
+        type:
+          type: string
+        title:
+          type: string
+        status:
+          type: integer
+        detail:
+          type: string
+        traceId:
+          type: string
+        error:
+          type: string
+`
+
+	expectedProblem := strings.TrimPrefix(`
+// Code generated by openapi3-go-gen. DO NOT EDIT.
+
+package openapi
+
+import (
+	"errors"
+)
+
+type Problem struct {
+	Type    string `+"`json:\"type,omitempty\"`"+`
+	Title   string `+"`json:\"title\"`"+`
+	Status  int    `+"`json:\"status,omitempty\"`"+`
+	Detail  string `+"`json:\"detail,omitempty\"`"+`
+	TraceID string `+"`json:\"traceId,omitempty\"`"+`
+	Error_  string `+"`json:\"error,omitempty\"`"+`
+}
+
+func (instance *Problem) Validate() error {
+	if instance.Title == "" {
+		return errors.New("Value for field Title must be not empty")
+	}
+	return nil
+}
+
+func (instance *Problem) Error() string {
+	message := "Problem"
+
+	if instance.Title != "" {
+		message = instance.Title
+	}
+
+	if instance.Detail != "" {
+		message += ": " + instance.Detail
+	}
+
+	return message
+}
+`, "\n")
+
+	_, err := generateWithSpec(oasYaml)
+	require.NoError(t, err)
+
+	problem, err := readGoFile("problem.go")
+	require.NoError(t, err)
+	require.Equal(t, expectedProblem, problem)
+
+	problems, err := readGoFile("problem_response.go")
+	require.NoError(t, err)
+	require.Contains(t, problems, `
+func newListPetsProblem(statusCode int) error {
+	return &Problem{}
+}
+`)
+
+	specGo, err := readGoFile("spec.go")
+	require.NoError(t, err)
+	require.Contains(t, specGo, "specProblem := &Problem{}")
+	require.NotContains(t, specGo, "type ProblemDetails struct")
+
+	doc, err := generator.LoadSpec("oas.yml")
+	require.NoError(t, err)
+
+	models := generator.NewSchemaResolver(doc, generator.NewFlattener(doc, generator.Naming{}).Flatten(), generator.Naming{}).Resolve()
+
+	propNames := make([]string, 0)
+	for _, prop := range models["Problem"].Props {
+		propNames = append(propNames, prop.Name)
+	}
+
+	require.Equal(t, []string{"Type", "Title", "Status", "Detail", "TraceID", "Error_"}, propNames)
+	require.True(t, models["Problem"].IsProblem)
+	require.Equal(t, "Title", models["Problem"].ProblemTitle.Name)
+	require.Equal(t, "Detail", models["Problem"].ProblemDetail.Name)
+}
+
+func TestProblemPerStatusRuntime(t *testing.T) {
+	beforeTest(t)
+
+	oasYaml := `
+openapi: 3.0.3
+info:
+  title: Pets
+  version: 1.0.0
+servers:
+  - url: /v1
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      responses:
+        '200':
+          description: OK
+        default:
+          description: Error
+          content:
+            application/problem+json:
+              schema:
+                $ref: '#/components/schemas/Problem'
+  /pets/{id}:
+    get:
+      operationId: getPet
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema:
+            type: integer
+      responses:
+        '200':
+          description: OK
+        '404':
+          description: Not found
+          content:
+            application/problem+json:
+              schema:
+                type: object
+                properties:
+                  title:
+                    type: string
+                  petId:
+                    type: integer
+        4XX:
+          description: Client error
+          content:
+            application/problem+json:
+              schema:
+                $ref: '#/components/schemas/Problem'
+components:
+  schemas:
+    Problem:
+      type: object
+      properties:
+        title:
+          type: string
+        detail:
+          type: string
+`
+
+	_, err := generateWithSpec(oasYaml)
+	require.NoError(t, err)
+
+	problems, err := readGoFile("problem_response.go")
+	require.NoError(t, err)
+	require.Contains(t, problems, `
+func newGetPetProblem(statusCode int) error {
+	switch {
+	case statusCode == 404:
+		return &GetPet404Problem{}
+	case statusCode/100 == 4:
+		return &Problem{}
+	}
+
+	return nil
+}
+`)
+	require.Contains(t, problems, `
+var problemOperations = map[string]func(statusCode int) error{
+	"GET /pets":      newListPetsProblem,
+	"GET /pets/{id}": newGetPetProblem,
+}
+`)
+
+	testGenerated(t, map[string]string{"problem_test.go": `package openapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func TestProblemFromResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status, _ := strconv.Atoi(r.Header.Get("X-Status"))
+		w.Header().Set("Content-Type", "application/problem+json")
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(` + "`" + `{"title":"Oops","detail":"Details","petId":7}` + "`" + `))
+	}))
+	defer server.Close()
+
+	client := NewClient(&http.Client{Transport: statusTransport{}})
+	client.URLs.ServerURL = server.URL + "/v1"
+
+	ctx := context.WithValue(context.Background(), statusKey{}, "404")
+
+	var notFound *GetPet404Problem
+	resp, err := client.GetPet(ctx, 7)
+	if !errors.As(err, &notFound) || notFound.PetID != 7 || resp.StatusCode != http.StatusNotFound {
+		t.Errorf("got %#v for a 404", err)
+	}
+
+	if err := ProblemFromResponse(resp); !errors.As(err, &notFound) || notFound.Title != "Oops" {
+		t.Errorf("got %#v decoding the returned 404 again", err)
+	}
+
+	var problem *Problem
+	if _, err := client.GetPet(context.WithValue(context.Background(), statusKey{}, "409"), 7); !errors.As(err, &problem) || problem.Detail != "Details" {
+		t.Errorf("got %#v for a 409", err)
+	}
+
+	if _, err := client.GetPet(context.WithValue(context.Background(), statusKey{}, "500"), 7); err == nil || err.Error() != "unexpected response status 500" {
+		t.Errorf("got %#v for an undeclared 500", err)
+	}
+
+	if _, err := client.ListPets(context.WithValue(context.Background(), statusKey{}, "503")); !errors.As(err, &problem) || problem.Title != "Oops" {
+		t.Errorf("got %#v for the default response", err)
+	}
+
+	resp, err = client.ListPets(context.WithValue(context.Background(), statusKey{}, "200"))
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Errorf("got %#v for a 200", err)
+	}
+}
+
+type statusKey struct{}
+
+type statusTransport struct{}
+
+func (statusTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("X-Status", req.Context().Value(statusKey{}).(string))
+
+	return http.DefaultTransport.RoundTrip(req)
+}
+`})
+}
+
+func TestPagination(t *testing.T) {
+	beforeTest(t)
+
+	oasYaml := `
+openapi: "3.0.0"
+info:
+  title: "Test"
+  version: "1.0.0"
+paths:
+  /orgs/{org}/users:
+    get:
+      operationId: listUsers
+      x-pagination:
+        cursorParam: cursor
+        nextCursorField: nextCursor
+        itemsField: items
+      parameters:
+        - name: org
+          in: path
+          required: true
+          schema:
+            type: string
+        - name: cursor
+          in: query
+          schema:
+            type: string
+        - name: X-Tenant
+          in: header
+          schema:
+            type: string
+      responses:
+        "200":
+          description: OK
+          content:
+            application/json:
+              schema:
+                $ref: "#/components/schemas/UserPage"
+        default:
+          description: Error
+          content:
+            application/problem+json:
+              schema:
+                $ref: "#/components/schemas/Problem"
+components:
+  schemas:
+    Problem:
+      type: object
+      properties:
+        title:
+          type: string
+    User:
+      type: object
+      properties:
+        name:
+          type: string
+    UserPage:
+      type: object
+      properties:
+        items:
+          type: array
+          items:
+            $ref: "#/components/schemas/User"
+        nextCursor:
+          type: string
+`
+
+	expectedPagination := []string{`
+func ListUsersAll(ctx context.Context, client ClientInterface, org string, params ListUsersParams) *ListUsersIterator {
+`, `
+func (it *ListUsersIterator) Item() User {
+	return it.item
+}
+`, `
+	resp, err := it.send(it.ctx, it.params)
+
+	page := &UserPage{}
+	if err := decodePage(resp, err, page); err != nil {
+		return err
+	}
+
+	it.items = page.Items
+
+	next := page.NextCursor
+
+	current := ""
+	if it.params.Cursor != nil {
+		current = *it.params.Cursor
+	}
+
+	if next == "" || next == current {
+		it.done = true
+		return nil
+	}
+
+	it.params.Cursor = &next
+
+	return nil
+}
+`}
+
+	_, err := generateWithSpec(oasYaml)
+	require.NoError(t, err)
+
+	pagination, err := readGoFile("pagination.go")
+	require.NoError(t, err)
+
+	for _, expected := range expectedPagination {
+		require.Contains(t, pagination, expected)
+	}
+
+	testGenerated(t, map[string]string{"pagination_test.go": `package openapi
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestListUsersAll(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/orgs/acme/users" || r.Header.Get("X-Tenant") != "blue" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		if r.URL.Query().Get("cursor") == "" {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(` + "`" + `{"items":[{"name":"Ann"},{"name":"Bob"}],"nextCursor":"2"}` + "`" + `))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/problem+json")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte(` + "`" + `{"title":"Try later"}` + "`" + `))
+	}))
+	defer server.Close()
+
+	tenant := "blue"
+	client := &Client{URLs: &URLBuilder{ServerURL: server.URL}, HTTPClient: server.Client()}
+
+	it := ListUsersAll(context.Background(), client, "acme", ListUsersParams{XTenant: &tenant})
+
+	names := make([]string, 0)
+	for it.Next() {
+		names = append(names, it.Item().Name)
+	}
+
+	if len(names) != 2 || names[0] != "Ann" || names[1] != "Bob" {
+		t.Errorf("got items %v", names)
+	}
+
+	var problem *Problem
+	if !errors.As(it.Err(), &problem) || problem.Title != "Try later" {
+		t.Errorf("got error %#v", it.Err())
+	}
+}
+
+func TestListUsersAllFakeClient(t *testing.T) {
+	pages := map[string]string{
+		"":  ` + "`" + `{"items":[{"name":"Ann"}],"nextCursor":"2"}` + "`" + `,
+		"2": ` + "`" + `{"items":[{"name":"Bob"}]}` + "`" + `,
+	}
+
+	fake := &FakeClient{
+		ListUsersFunc: func(ctx context.Context, org string, params ListUsersParams) (*http.Response, error) {
+			cursor := ""
+			if params.Cursor != nil {
+				cursor = *params.Cursor
+			}
+
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(pages[cursor]))}, nil
+		},
+	}
+
+	it := ListUsersAll(context.Background(), fake, "acme", ListUsersParams{})
+
+	names := make([]string, 0)
+	for it.Next() {
+		names = append(names, it.Item().Name)
+	}
+
+	if it.Err() != nil || len(names) != 2 || names[0] != "Ann" || names[1] != "Bob" {
+		t.Errorf("got items %v and error %v", names, it.Err())
+	}
+
+	calls := fake.ListUsersCalls()
+	if len(calls) != 2 || calls[0].Org != "acme" || calls[1].Params.Cursor == nil || *calls[1].Params.Cursor != "2" {
+		t.Errorf("got calls %#v", calls)
+	}
+}
+`})
+}
+
+func TestOperationFilter(t *testing.T) {
+	oasYaml := `
+openapi: "3.0.0"
+info:
+  title: "Test"
+  version: "1.0.0"
+paths:
+  /users:
+    get:
+      operationId: listUsers
+      tags: [users]
+      responses:
+        "200":
+          description: OK
+          content:
+            application/json:
+              schema:
+                $ref: "#/components/schemas/UserPage"
+  /users/{id}/audit:
+    get:
+      operationId: auditUser
+      tags: [users]
+      x-internal: true
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema:
+            type: string
+      responses:
+        "200":
+          description: OK
+          content:
+            application/json:
+              schema:
+                $ref: "#/components/schemas/Audit"
+  /orders:
+    get:
+      operationId: listOrders
+      tags: [orders]
+      responses:
+        "200":
+          description: OK
+          content:
+            application/json:
+              schema:
+                $ref: "#/components/schemas/Order"
+components:
+  schemas:
+    User:
+      type: object
+      properties:
+        name:
+          type: string
+    UserPage:
+      type: object
+      properties:
+        items:
+          type: array
+          items:
+            $ref: "#/components/schemas/User"
+    Audit:
+      type: object
+      properties:
+        actor:
+          $ref: "#/components/schemas/User"
+    Order:
+      type: object
+      properties:
+        id:
+          type: string
+    Legacy:
+      type: object
+      properties:
+        id:
+          type: string
+`
+
+	doc, err := spec3.NewLoader().LoadFromData([]byte(oasYaml))
+	require.NoError(t, err)
+
+	operationFilter := generator.NewOperationFilter(doc, generator.FilterOptions{
+		IncludeTags:     []string{"users"},
+		ExcludeInternal: true,
+	})
+	require.NoError(t, operationFilter.Filter())
+
+	require.Equal(t, []generator.SkippedComponent{
+		{Name: "Audit", Reason: "only referenced from filtered operation auditUser"},
+		{Name: "Legacy", Reason: "unreferenced by any kept operation"},
+		{Name: "Order", Reason: "only referenced from filtered operation listOrders"},
+	}, operationFilter.Skipped())
+
+	require.Len(t, doc.Paths, 1)
+	require.NotNil(t, doc.Paths["/users"])
+
+	models := generator.NewSchemaResolver(doc, generator.NewFlattener(doc, generator.Naming{}).Flatten(), generator.Naming{}).Resolve()
+
+	names := make([]string, 0, len(models))
+	for name := range models {
+		names = append(names, name)
+	}
+
+	require.ElementsMatch(t, []string{"User", "UserPage"}, names)
+
+	doc, err = spec3.NewLoader().LoadFromData([]byte(oasYaml))
+	require.NoError(t, err)
+
+	err = generator.NewOperationFilter(doc, generator.FilterOptions{
+		ExcludePaths:        []string{"/orders"},
+		IncludeOperationIDs: []string{"auditUser"},
+	}).Filter()
+	require.NoError(t, err)
+
+	require.Len(t, doc.Paths, 1)
+	require.NotNil(t, doc.Paths["/users/{id}/audit"])
+	require.Len(t, doc.Components.Schemas, 2)
+	require.NotNil(t, doc.Components.Schemas["Audit"])
+	require.NotNil(t, doc.Components.Schemas["User"])
+}
+
+func TestSchemaPruner(t *testing.T) {
+	oasYaml := `
+openapi: "3.0.0"
+info:
+  title: "Test"
+  version: "1.0.0"
+paths:
+  /invoices/{id}:
+    get:
+      operationId: getInvoice
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema:
+            type: string
+      responses:
+        "200":
+          description: OK
+          content:
+            application/json:
+              schema:
+                $ref: "#/components/schemas/Invoice"
+components:
+  schemas:
+    Address:
+      type: object
+      properties:
+        city:
+          type: string
+    Customer:
+      type: object
+      properties:
+        address:
+          $ref: "#/components/schemas/Address"
+    CustomerList:
+      type: array
+      items:
+        $ref: "#/components/schemas/Customer"
+    Invoice:
+      type: object
+      properties:
+        total:
+          type: number
+    Unused:
+      type: object
+      properties:
+        flag:
+          $ref: "#/components/schemas/Flag"
+    Flag:
+      type: object
+      properties:
+        enabled:
+          type: boolean
+`
+
+	doc, err := spec3.NewLoader().LoadFromData([]byte(oasYaml))
+	require.NoError(t, err)
+
+	skipped, err := generator.NewSchemaPruner(doc, []string{"Customer.*"}).Prune()
+	require.NoError(t, err)
+
+	require.Equal(t, []generator.SkippedComponent{
+		{Name: "Flag", Reason: "only referenced from skipped component Unused"},
+		{Name: "Invoice", Reason: "only referenced from operation getInvoice, not from roots Customer, CustomerList"},
+		{Name: "Unused", Reason: "unreferenced by roots Customer, CustomerList and any operation"},
+	}, skipped)
+
+	models := generator.NewSchemaResolver(doc, generator.NewFlattener(doc, generator.Naming{}).Flatten(), generator.Naming{}).Resolve()
+	require.Contains(t, models, "Address")
+	require.NotContains(t, models, "Invoice")
+
+	_, err = generator.NewSchemaPruner(doc, []string{"Missing"}).Prune()
+	require.EqualError(t, err, "root Missing matches no component schema")
+}
+
+func TestOpenAPI31(t *testing.T) {
+	beforeTest(t)
+
+	oasYaml := `
+openapi: "3.1.0"
+info:
+  title: "Test"
+  version: "1.0.0"
+components:
+  schemas:
+    Order:
+      type: object
+      required: [kind, quantity]
+      properties:
+        kind:
+          const: order
+        note:
+          type: [string, "null"]
+          examples: ["fragile"]
+        quantity:
+          type: integer
+          exclusiveMinimum: 0
+        point:
+          type: array
+          prefixItems:
+            - type: number
+            - type: number
+          items: false
+        pair:
+          prefixItems:
+            - type: string
+            - type: integer
+        address:
+          anyOf:
+            - $ref: "#/components/schemas/Order/$defs/Address"
+            - type: "null"
+      $defs:
+        Address:
+          type: object
+          properties:
+            city:
+              type: string
+`
+
+	expectedOrder := strings.TrimPrefix(`
+// Code generated by openapi3-go-gen. DO NOT EDIT.
+
+package openapi
+
+import (
+	"errors"
+)
+
+type Order struct {
+	// Constraints: one of order
+	Kind string `+"`json:\"kind\"`"+`
+	// Example: "fragile"
+	Note *string `+"`json:\"note,omitempty\"`"+`
+	// Constraints: exclusive minimum 0
+	Quantity int `+"`json:\"quantity\"`"+`
+	// Constraints: minItems 2, maxItems 2
+	Point []float64 `+"`json:\"point,omitempty\"`"+`
+	// Constraints: minItems 2
+	Pair    []interface{} `+"`json:\"pair,omitempty\"`"+`
+	Address *Address      `+"`json:\"address,omitempty\"`"+`
+}
+
+func (instance *Order) Validate() error {
+	if instance.Kind == "" {
+		return errors.New("Value for field Kind must be not empty")
+	}
+	containsKind := false
+	enumKind := []string{"order"}
+	for _, v := range enumKind {
+		if v == instance.Kind {
+			containsKind = true
+			break
+		}
+	}
+
+	if !containsKind {
+		return errors.New("Value for field Kind is not allowed")
+	}
+	if instance.Quantity <= 0 {
+		return errors.New("Field Quantity should not be less or equal than 0")
+	}
+	if len(instance.Point) > 2 {
+		return errors.New("Number of elements of Point should not exceed 2")
+	}
+	if len(instance.Point) < 2 {
+		return errors.New("Number of elements of Point should not be less than 2")
+	}
+	if len(instance.Pair) < 2 {
+		return errors.New("Number of elements of Pair should not be less than 2")
+	}
+	return nil
+}
+`, "\n")
+
+	_, err := generateWithSpec(oasYaml)
+	require.NoError(t, err)
+
+	order, err := readGoFile("order.go")
+	require.NoError(t, err)
+	require.Equal(t, expectedOrder, order)
+
+	address, err := readGoFile("address.go")
+	require.NoError(t, err)
+	require.Contains(t, address, "type Address struct {")
+}
+
+func TestOpenAPI31DefCollisions(t *testing.T) {
+	beforeTest(t)
+
+	oasYaml := `
+openapi: "3.1.0"
+info:
+  title: "Test"
+  version: "1.0.0"
+components:
+  schemas:
+    Tag:
+      type: object
+      properties:
+        label:
+          type: string
+    Pet:
+      allOf:
+        - type: object
+          $defs:
+            Tag:
+              type: object
+              properties:
+                name:
+                  type: string
+          properties:
+            tag:
+              $ref: "#/components/schemas/Pet/allOf/0/$defs/Tag"
+        - type: object
+          $defs:
+            Tag:
+              type: object
+              properties:
+                color:
+                  type: string
+          properties:
+            marker:
+              $ref: "#/components/schemas/Pet/allOf/1/$defs/Tag"
+`
+
+	_, err := generateWithSpec(oasYaml)
+	require.NoError(t, err)
+
+	petTag, err := readGoFile("pet_tag.go")
+	require.NoError(t, err)
+	require.Contains(t, petTag, "\tName string `json:\"name,omitempty\"`\n")
+
+	petTag2, err := readGoFile("pet_tag_2.go")
+	require.NoError(t, err)
+	require.Contains(t, petTag2, "\tColor string `json:\"color,omitempty\"`\n")
+
+	pet, err := readGoFile("pet.go")
+	require.NoError(t, err)
+	require.Regexp(t, `Tag +\*?PetTag +`+"`json:\"tag,omitempty\"`", pet)
+	require.Regexp(t, `Marker +\*?PetTag2 +`+"`json:\"marker,omitempty\"`", pet)
+}
+
+func TestOpenAPI31Webhooks(t *testing.T) {
+	beforeTest(t)
+
+	oasYaml := `
+openapi: "3.1.0"
+info:
+  title: "Test"
+  version: "1.0.0"
+webhooks:
+  newPet:
+    post:
+      requestBody:
+        content:
+          application/json:
+            schema:
+              $ref: "#/components/schemas/Pet"
+      responses:
+        "200":
+          description: Received
+  petRemoved:
+    post:
+      operationId: onPetRemoved
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object
+              properties:
+                id:
+                  type: integer
+      responses:
+        "204":
+          description: Received
+components:
+  schemas:
+    Pet:
+      type: object
+      required: [name]
+      properties:
+        name:
+          type: string
+`
+
+	expectedCallbacks := []string{`
+func SendNewPet(ctx context.Context, client HTTPRequestDoer, callbackURL string, body *Pet) (*http.Response, error) {
+`, `
+type NewPetReceiver interface {
+	HandleNewPet(ctx context.Context, body *Pet) error
+}
+`, `
+func NewNewPetHandler(receiver NewPetReceiver) http.Handler {
+`, `
+type OnPetRemovedReceiver interface {
+	HandleOnPetRemoved(ctx context.Context, body *OnPetRemovedBody) error
+}
+`}
+
+	_, err := generateWithSpec(oasYaml)
+	require.NoError(t, err)
+
+	_, err = readGoFile("pet.go")
+	require.NoError(t, err)
+
+	callbacks, err := readGoFile("callback.go")
+	require.NoError(t, err)
+
+	for _, expected := range expectedCallbacks {
+		require.Contains(t, callbacks, expected)
+	}
+
+	require.NotContains(t, callbacks, "ResolveNewPetURL")
+
+	testGenerated(t, map[string]string{"webhook_test.go": `package openapi
+
+import "testing"
+
+func TestWebhookCompiles(t *testing.T) {
+	var _ NewPetReceiver
+	_ = NewNewPetHandler
+	_ = SendOnPetRemoved
+}
+`})
+}
+
+func TestOpenAPI31ConstsAndCompositions(t *testing.T) {
+	beforeTest(t)
+
+	oasYaml := `
+openapi: "3.1.0"
+info:
+  title: "Test"
+  version: "1.0.0"
+components:
+  schemas:
+    Feature:
+      type: object
+      required: [version, enabled]
+      properties:
+        version:
+          const: 2
+        enabled:
+          const: true
+        level:
+          type: [integer, "null"]
+          enum: [1, 2, null]
+    Tag:
+      type: object
+      properties:
+        label:
+          type: string
+    Pet:
+      allOf:
+        - type: object
+          $defs:
+            Tag:
+              type: object
+              properties:
+                name:
+                  type: string
+          properties:
+            tag:
+              $ref: "#/components/schemas/Pet/allOf/0/$defs/Tag"
+        - type: object
+          properties:
+            id:
+              type: integer
+`
+
+	expectedFeature := []string{`
+	enumVersion := []int{2}
+	for _, v := range enumVersion {
+		if v == instance.Version {
+`, `
+	enumEnabled := []bool{true}
+	for _, v := range enumEnabled {
+		if v == instance.Enabled {
+`, `
+	containsLevel := instance.Level == nil
+	enumLevel := []int{1, 2}
+	for _, v := range enumLevel {
+		if !containsLevel && v == *instance.Level {
+`}
+
+	_, err := generateWithSpec(oasYaml)
+	require.NoError(t, err)
+
+	feature, err := readGoFile("feature.go")
+	require.NoError(t, err)
+
+	for _, expected := range expectedFeature {
+		require.Contains(t, feature, expected)
+	}
+
+	petTag, err := readGoFile("pet_tag.go")
+	require.NoError(t, err)
+	require.Contains(t, petTag, "type PetTag struct {")
+
+	pet, err := readGoFile("pet.go")
+	require.NoError(t, err)
+	require.Regexp(t, `Tag +\*?PetTag +`+"`json:\"tag,omitempty\"`", pet)
+
+	testGenerated(t, map[string]string{"feature_test.go": `package openapi
+
+import "testing"
+
+func TestFeatureValidate(t *testing.T) {
+	level := 2
+	invalidLevel := 3
+
+	for _, tc := range []struct {
+		feature Feature
+		valid bool
+	}{
+		{Feature{Version: 2, Enabled: true}, true},
+		{Feature{Version: 2, Enabled: true, Level: &level}, true},
+		{Feature{Version: 1, Enabled: true}, false},
+		{Feature{Version: 2, Enabled: false}, false},
+		{Feature{Version: 2, Enabled: true, Level: &invalidLevel}, false},
+	} {
+		if err := tc.feature.Validate(); (err == nil) != tc.valid {
+			t.Errorf("Validate of %+v returned %v", tc.feature, err)
+		}
+	}
+}
+`})
+}
+
+func TestSwagger2(t *testing.T) {
+	beforeTest(t)
+
+	oasYaml := `
+swagger: "2.0"
+info:
+  title: Test
+  version: "1.0"
+host: api.example.com
+basePath: /v1
+paths:
+  /pets:
+    post:
+      operationId: createPet
+      consumes: [application/json]
+      parameters:
+        - name: body
+          in: body
+          required: true
+          schema:
+            $ref: "#/definitions/Pet"
+      responses:
+        "200":
+          description: OK
+          schema:
+            $ref: "#/definitions/Pet"
+  /pets/{id}/photo:
+    post:
+      operationId: uploadPhoto
+      consumes: [multipart/form-data]
+      parameters:
+        - name: id
+          in: path
+          required: true
+          type: string
+        - name: caption
+          in: formData
+          type: string
+        - name: file
+          in: formData
+          type: file
+      responses:
+        "200":
+          description: OK
+definitions:
+  Pet:
+    type: object
+    required: [name]
+    properties:
+      name:
+        type: string
+      tags:
+        type: array
+        items:
+          type: string
+`
+
+	expectedPet := strings.TrimPrefix(`
+// Code generated by openapi3-go-gen. DO NOT EDIT.
+
+package openapi
+
+import (
+	"errors"
+)
+
+type Pet struct {
+	Name string   `+"`json:\"name\"`"+`
+	Tags []string `+"`json:\"tags,omitempty\"`"+`
+}
+
+func (instance *Pet) Validate() error {
+	if instance.Name == "" {
+		return errors.New("Value for field Name must be not empty")
+	}
+	return nil
+}
+`, "\n")
+
+	expectedBody := strings.TrimPrefix(`
+// Code generated by openapi3-go-gen. DO NOT EDIT.
+
+package openapi
+
+type UploadPhotoBody struct {
+	Caption string    `+"`json:\"caption,omitempty\"`"+`
+	File    *FormFile `+"`json:\"file,omitempty\"`"+`
+}
+
+func (instance *UploadPhotoBody) Validate() error {
+	return nil
+}
+`, "\n")
+
+	doc, err := generateWithSpec(oasYaml)
+	require.NoError(t, err)
+	require.Equal(t, "3.0.3", doc.OpenAPI)
+
+	pet, err := readGoFile("pet.go")
+	require.NoError(t, err)
+	require.Equal(t, expectedPet, pet)
+
+	body, err := readGoFile("upload_photo_body.go")
+	require.NoError(t, err)
+	require.Equal(t, expectedBody, body)
+
+	urls, err := readGoFile("url.go")
+	require.NoError(t, err)
+	require.Contains(t, urls, `ServerURL: "https://api.example.com/v1",`)
+}
+
+func TestJSONSchemaInput(t *testing.T) {
+	beforeTest(t)
+
+	orderSchema := `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "$id": "https://example.com/order.schema.json",
+  "title": "OrderPlaced",
+  "type": "object",
+  "required": ["orderId", "customer"],
+  "properties": {
+    "orderId": {"type": "string", "format": "uuid"},
+    "customer": {"$ref": "customer.json"},
+    "lines": {"type": "array", "items": {"$ref": "#/$defs/Line"}},
+    "note": {"type": ["string", "null"]}
+  },
+  "$defs": {
+    "Line": {
+      "type": "object",
+      "properties": {
+        "sku": {"type": "string"},
+        "quantity": {"type": "integer", "exclusiveMinimum": 0}
+      }
+    }
+  }
+}
+`
+
+	customerSchema := `{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "type": "object",
+  "properties": {
+    "name": {"type": "string"},
+    "address": {"$ref": "#/definitions/address"}
+  },
+  "definitions": {
+    "address": {
+      "type": "object",
+      "properties": {
+        "city": {"type": "string", "maxLength": 10}
+      }
+    }
+  }
+}
+`
+
+	expectedOrder := strings.TrimPrefix(`
+// Code generated by openapi3-go-gen. DO NOT EDIT.
+
+package openapi
+
+import (
+	"errors"
+)
+
+type OrderPlaced struct {
+	OrderID  string   `+"`json:\"orderId\"`"+`
+	Customer Customer `+"`json:\"customer\"`"+`
+	Lines    []Line   `+"`json:\"lines,omitempty\"`"+`
+	Note     *string  `+"`json:\"note,omitempty\"`"+`
+}
+
+func (instance *OrderPlaced) Validate() error {
+	if instance.OrderID == "" {
+		return errors.New("Value for field OrderID must be not empty")
+	}
+	return nil
+}
+`, "\n")
+
+	expectedCustomer := strings.TrimPrefix(`
+// Code generated by openapi3-go-gen. DO NOT EDIT.
+
+package openapi
+
+type Customer struct {
+	Name    string  `+"`json:\"name,omitempty\"`"+`
+	Address Address `+"`json:\"address,omitempty\"`"+`
+}
+
+func (instance *Customer) Validate() error {
+	return nil
+}
+`, "\n")
+
+	dir := t.TempDir()
+
+	orderPath := filepath.Join(dir, "order.schema.json")
+	require.NoError(t, os.WriteFile(orderPath, []byte(orderSchema), 0666))
+
+	customerPath := filepath.Join(dir, "customer.json")
+	require.NoError(t, os.WriteFile(customerPath, []byte(customerSchema), 0666))
+
+	doc, err := generator.LoadSpec(orderPath, customerPath)
+	require.NoError(t, err)
+
+	models := generator.NewSchemaResolver(doc, generator.NewFlattener(doc, generator.Naming{}).Flatten(), generator.Naming{}).Resolve()
+
+	err = generator.NewGenerator(generator.Naming{}).GenerateToFile(models, "gen")
+	require.NoError(t, err)
+
+	order, err := readGoFile("order_placed.go")
+	require.NoError(t, err)
+	require.Equal(t, expectedOrder, order)
+
+	customer, err := readGoFile("customer.go")
+	require.NoError(t, err)
+	require.Equal(t, expectedCustomer, customer)
+
+	require.FileExists(t, "gen/line.go")
+	require.FileExists(t, "gen/address.go")
+}
+
+func TestJSONSchemaInputPaths(t *testing.T) {
+	beforeTest(t)
+
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "shipping"), 0777))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "billing"), 0777))
+
+	files := map[string]string{
+		"shipping/address.json": `{"title": "ShippingAddress", "type": "object", "properties": {"street": {"type": "string"}}}`,
+		"billing/address.json":  `{"title": "BillingAddress", "type": "object", "properties": {"iban": {"type": "string"}}}`,
+		"shipping/event.json": `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "title": "Event",
+  "type": "object",
+  "properties": {
+    "shipping": {"$ref": "address.json"},
+    "billing": {"$ref": "../billing/address.json"},
+    "note": {"$ref": "#/$defs/ShippingAddress"}
+  },
+  "$defs": {
+    "ShippingAddress": {"type": "object", "properties": {"text": {"type": "string"}}}
+  }
+}`,
+		"settings.yaml": "name: settings\nretries: 3\n",
+	}
+
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0666))
+	}
+
+	doc, err := generator.LoadSpec(
+		filepath.Join(dir, "shipping/address.json"),
+		filepath.Join(dir, "billing/address.json"),
+		filepath.Join(dir, "shipping/event.json"),
+	)
+	require.NoError(t, err)
+
+	models := generator.NewSchemaResolver(doc, generator.NewFlattener(doc, generator.Naming{}).Flatten(), generator.Naming{}).Resolve()
+
+	require.NoError(t, generator.NewGenerator(generator.Naming{}).GenerateToFile(models, "gen"))
+
+	event, err := readGoFile("event.go")
+	require.NoError(t, err)
+	require.Contains(t, event, `
+type Event struct {
+	Shipping ShippingAddress      `+"`json:\"shipping,omitempty\"`"+`
+	Billing  BillingAddress       `+"`json:\"billing,omitempty\"`"+`
+	Note     EventShippingAddress `+"`json:\"note,omitempty\"`"+`
+}
+`)
+
+	_, err = generator.LoadSpec(filepath.Join(dir, "settings.yaml"))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "is neither an OpenAPI, Swagger, AsyncAPI nor a JSON Schema document")
+}
+
+func TestAsyncAPI(t *testing.T) {
+	beforeTest(t)
+
+	asyncAPI := `
+asyncapi: 2.6.0
+info:
+  title: Users
+  version: 1.0.0
+channels:
+  user/signedup:
+    subscribe:
+      message:
+        $ref: '#/components/messages/UserSignedUp'
+  user/deleted:
+    publish:
+      message:
+        name: userDeleted
+        payload:
+          type: object
+          properties:
+            id:
+              type: string
+components:
+  messages:
+    UserSignedUp:
+      headers:
+        type: object
+        properties:
+          correlationId:
+            type: string
+      payload:
+        $ref: '#/components/schemas/User'
+  schemas:
+    User:
+      type: object
+      required:
+        - email
+      properties:
+        email:
+          type: string
+          format: email
+        age:
+          type: [integer, "null"]
+`
+
+	expectedHeader := strings.TrimPrefix(`
+// Code generated by openapi3-go-gen. DO NOT EDIT.
+
+package openapi
+
+type UserSignedUpHeader struct {
+	CorrelationID string `+"`json:\"correlationId,omitempty\"`"+`
+}
+
+func (instance *UserSignedUpHeader) Validate() error {
+	return nil
+}
+`, "\n")
+
+	expectedDeleted := strings.TrimPrefix(`
+// Code generated by openapi3-go-gen. DO NOT EDIT.
+
+package openapi
+
+type UserDeleted struct {
+	ID string `+"`json:\"id,omitempty\"`"+`
+}
+
+func (instance *UserDeleted) Validate() error {
+	return nil
+}
+`, "\n")
+
+	expectedAsync := strings.TrimPrefix(`
+// Code generated by openapi3-go-gen. DO NOT EDIT.
+
+package openapi
+
+import (
+	"context"
+)
+
+const UserDeletedChannelAddress = "user/deleted"
+
+type UserDeletedPublisher interface {
+	PublishUserDeleted(ctx context.Context, payload *UserDeleted) error
+}
+
+type UserDeletedSubscriber interface {
+	SubscribeUserDeleted(ctx context.Context, handler func(ctx context.Context, payload *UserDeleted) error) error
+}
+
+const UserSignedupChannelAddress = "user/signedup"
+
+type UserSignedupPublisher interface {
+	PublishUserSignedUp(ctx context.Context, payload *User, headers *UserSignedUpHeader) error
+}
+
+type UserSignedupSubscriber interface {
+	SubscribeUserSignedUp(ctx context.Context, handler func(ctx context.Context, payload *User, headers *UserSignedUpHeader) error) error
+}
+`, "\n")
+
+	_, err := generateWithSpec(asyncAPI)
+	require.NoError(t, err)
+
+	header, err := readGoFile("user_signed_up_header.go")
+	require.NoError(t, err)
+	require.Equal(t, expectedHeader, header)
+
+	deleted, err := readGoFile("user_deleted.go")
+	require.NoError(t, err)
+	require.Equal(t, expectedDeleted, deleted)
+
+	async, err := readGoFile("async.go")
+	require.NoError(t, err)
+	require.Equal(t, expectedAsync, async)
+
+	require.FileExists(t, "gen/user.go")
+}
+
+func TestAsyncAPIScalarAndArrayPayloads(t *testing.T) {
+	beforeTest(t)
+
+	asyncAPI := `
+asyncapi: 2.6.0
+info:
+  title: Health
+  version: 1.0.0
+channels:
+  ping:
+    publish:
+      message:
+        name: ping
+        payload:
+          type: string
+  batch:
+    publish:
+      message:
+        name: batch
+        payload:
+          type: array
+          items:
+            type: object
+            properties:
+              id:
+                type: integer
+`
+
+	expectedItem := strings.TrimPrefix(`
+// Code generated by openapi3-go-gen. DO NOT EDIT.
+
+package openapi
+
+type BatchItem struct {
+	ID int `+"`json:\"id,omitempty\"`"+`
+}
+
+func (instance *BatchItem) Validate() error {
+	return nil
+}
+`, "\n")
+
+	expectedAsync := strings.TrimPrefix(`
+// Code generated by openapi3-go-gen. DO NOT EDIT.
+
+package openapi
+
+import (
+	"context"
+)
+
+const BatchChannelAddress = "batch"
+
+type BatchPublisher interface {
+	PublishBatch(ctx context.Context, payload []BatchItem) error
+}
+
+type BatchSubscriber interface {
+	SubscribeBatch(ctx context.Context, handler func(ctx context.Context, payload []BatchItem) error) error
+}
+
+const PingChannelAddress = "ping"
+
+type PingPublisher interface {
+	PublishPing(ctx context.Context, payload string) error
+}
+
+type PingSubscriber interface {
+	SubscribePing(ctx context.Context, handler func(ctx context.Context, payload string) error) error
+}
+`, "\n")
+
+	_, err := generateWithSpec(asyncAPI)
+	require.NoError(t, err)
+
+	item, err := readGoFile("batch_item.go")
+	require.NoError(t, err)
+	require.Equal(t, expectedItem, item)
+
+	async, err := readGoFile("async.go")
+	require.NoError(t, err)
+	require.Equal(t, expectedAsync, async)
+
+	require.NoFileExists(t, "gen/ping.go")
+	require.NoFileExists(t, "gen/batch.go")
+
+	testGenerated(t, map[string]string{"async_test.go": asyncPayloadsTestGo})
+}
+
+const asyncPayloadsTestGo = `package openapi
+
+import (
+	"context"
+	"testing"
+)
+
+type recorder struct {
+	pings   []string
+	batches [][]BatchItem
+}
+
+func (r *recorder) PublishPing(ctx context.Context, payload string) error {
+	r.pings = append(r.pings, payload)
+	return nil
+}
+
+func (r *recorder) PublishBatch(ctx context.Context, payload []BatchItem) error {
+	r.batches = append(r.batches, payload)
+	return nil
+}
+
+var (
+	_ PingPublisher  = (*recorder)(nil)
+	_ BatchPublisher = (*recorder)(nil)
+)
+
+func TestPublish(t *testing.T) {
+	r := &recorder{}
+
+	if err := r.PublishPing(context.Background(), "ping"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.PublishBatch(context.Background(), []BatchItem{{ID: 1}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(r.pings) != 1 || len(r.batches) != 1 || r.batches[0][0].ID != 1 {
+		t.Fatalf("unexpected calls %+v", r)
+	}
+}
+`
+
+func TestExternalRefNaming(t *testing.T) {
+	beforeTest(t)
+
+	rootYaml := `
+openapi: 3.0.3
+info:
+  title: Pets
+  version: 1.0.0
+paths: {}
+components:
+  schemas:
+    Pet:
+      type: object
+      properties:
+        owner:
+          $ref: './common/openapi.yaml/#/components/schemas/UserProfile'
+        status:
+          $ref: 'common/status.json#/Status'
+        error:
+          $ref: 'a/error.yaml'
+        validation:
+          $ref: 'b/error.yaml'
+`
+
+	commonYaml := `
+components:
+  schemas:
+    UserProfile:
+      type: object
+      properties:
+        contact:
+          $ref: '#/components/schemas/Contact'
+        error:
+          $ref: '../a/error.yaml'
+    Contact:
+      type: object
+      properties:
+        email:
+          type: string
+`
+
+	statusJSON := `{"Status": {"title": "pet status", "type": "object", "properties": {"code": {"type": "integer"}}}}`
+
+	errorYaml := `
+type: object
+properties:
+  message:
+    type: string
+`
+
+	expectedPet := strings.TrimPrefix(`
+// Code generated by openapi3-go-gen. DO NOT EDIT.
+
+package openapi
+
+type Pet struct {
+	Owner      UserProfile     `+"`json:\"owner,omitempty\"`"+`
+	Status     PetStatus       `+"`json:\"status,omitempty\"`"+`
+	Error      Error           `+"`json:\"error,omitempty\"`"+`
+	Validation ValidationError `+"`json:\"validation,omitempty\"`"+`
+}
+
+func (instance *Pet) Validate() error {
+	return nil
+}
+`, "\n")
+
+	dir := t.TempDir()
+
+	files := map[string]string{
+		"openapi.yaml":        rootYaml,
+		"common/openapi.yaml": commonYaml,
+		"common/status.json":  statusJSON,
+		"a/error.yaml":        errorYaml,
+		"b/error.yaml":        errorYaml,
+	}
+
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0777))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0666))
+	}
+
+	rootPath := filepath.Join(dir, "openapi.yaml")
+
+	_, err := generator.LoadSpec(rootPath)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "model name Error of "+filepath.Join(dir, "b", "error.yaml")+" collides with "+filepath.Join(dir, "a", "error.yaml"))
+
+	doc, err := generator.LoadSpecWithOptions(generator.LoadOptions{
+		RefNames: map[string]string{filepath.Join(dir, "b", "error.yaml"): "ValidationError"},
+	}, rootPath)
+	require.NoError(t, err)
+
+	require.Equal(t, "#/components/schemas/Error", doc.Components.Schemas["UserProfile"].Value.Properties["error"].Ref)
+
+	models := generator.NewSchemaResolver(doc, generator.NewFlattener(doc, generator.Naming{}).Flatten(), generator.Naming{}).Resolve()
+
+	err = generator.NewGenerator(generator.Naming{}).GenerateToFile(models, "gen")
+	require.NoError(t, err)
+
+	pet, err := readGoFile("pet.go")
+	require.NoError(t, err)
+	require.Equal(t, expectedPet, pet)
+
+	for _, name := range []string{"user_profile.go", "contact.go", "pet_status.go", "error.go", "validation_error.go"} {
+		require.FileExists(t, filepath.Join("gen", name))
+	}
+}
+
+func TestModelNameCollisions(t *testing.T) {
+	beforeTest(t)
+
+	oasYaml := `
+openapi: 3.0.3
+info:
+  title: Collisions
+  version: 1.0.0
+paths: {}
+components:
+  schemas:
+    Foo:
+      type: object
+      properties:
+        bar:
+          type: object
+          properties:
+            inline:
+              type: string
+    FooBar:
+      type: object
+      properties:
+        component:
+          type: string
+    Pet:
+      type: object
+      x-go-name: Animal
+      properties:
+        name:
+          type: string
+    Owner:
+      type: object
+      properties:
+        pet:
+          $ref: '#/components/schemas/Pet'
+`
+
+	_, err := generateWithSpec(oasYaml)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "FooBar: #/components/schemas/FooBar and #/components/schemas/Foo/properties/bar")
+
+	beforeTest(t)
+
+	_, err = generateWithStrategy(oasYaml, generator.NamingStrategySuffix)
+	require.NoError(t, err)
+
+	foo, err := readGoFile("foo.go")
+	require.NoError(t, err)
+	require.Contains(t, foo, "\tBar FooBar2 `json:\"bar,omitempty\"`\n")
+
+	fooBar2, err := readGoFile("foo_bar_2.go")
+	require.NoError(t, err)
+	require.Contains(t, fooBar2, "\tInline string `json:\"inline,omitempty\"`\n")
+
+	fooBar, err := readGoFile("foo_bar.go")
+	require.NoError(t, err)
+	require.Contains(t, fooBar, "\tComponent string `json:\"component,omitempty\"`\n")
+
+	owner, err := readGoFile("owner.go")
+	require.NoError(t, err)
+	require.Contains(t, owner, "\tPet Animal `json:\"pet,omitempty\"`\n")
+	require.FileExists(t, "gen/animal.go")
+
+	beforeTest(t)
+
+	_, err = generateWithStrategy(oasYaml, generator.NamingStrategyPath)
+	require.NoError(t, err)
+
+	foo, err = readGoFile("foo.go")
+	require.NoError(t, err)
+	require.Contains(t, foo, "\tBar FooPropertiesBar `json:\"bar,omitempty\"`\n")
+	require.FileExists(t, "gen/foo_properties_bar.go")
+}
+
+func TestIdentifierSanitization(t *testing.T) {
+	beforeTest(t)
+
+	oasYaml := `
+openapi: 3.0.3
+info:
+  title: Identifiers
+  version: 1.0.0
+paths:
+  /skus/{sku}:
+    get:
+      operationId: get_sku
+      parameters:
+        - name: sku
+          in: path
+          required: true
+          schema:
+            type: string
+      responses:
+        "200":
+          description: OK
+          content:
+            application/json:
+              schema:
+                $ref: "#/components/schemas/api_response"
+  /links/{url}/{http}/{userId}/{HTTPServer}:
+    get:
+      operationId: getLink
+      parameters:
+        - name: url
+          in: path
+          required: true
+          schema:
+            type: string
+        - name: http
+          in: path
+          required: true
+          schema:
+            type: string
+        - name: userId
+          in: path
+          required: true
+          schema:
+            type: string
+        - name: HTTPServer
+          in: path
+          required: true
+          schema:
+            type: string
+      responses:
+        "204":
+          description: OK
+components:
+  schemas:
+    api_response:
+      type: object
+      properties:
+        id:
+          type: string
+        userUrl:
+          type: string
+        type:
+          type: string
+        2fa:
+          type: boolean
+        foo-bar.baz:
+          type: string
+        $ref:
+          type: string
+        name:
+          type: string
+        Name:
+          type: string
+        validate:
+          type: boolean
+        名前:
+          type: string
+        über:
+          type: string
+        sku:
+          type: string
+        HTTPServer:
+          type: string
+`
+
+	expectedResponse := strings.TrimPrefix(`
+// Code generated by openapi3-go-gen. DO NOT EDIT.
+
+package openapi
+
+type APIResponse struct {
+	ID         string `+"`json:\"id,omitempty\"`"+`
+	UserURL    string `+"`json:\"userUrl,omitempty\"`"+`
+	Type       string `+"`json:\"type,omitempty\"`"+`
+	N2fa       bool   `+"`json:\"2fa,omitempty\"`"+`
+	FooBarBaz  string `+"`json:\"foo-bar.baz,omitempty\"`"+`
+	Ref        string `+"`json:\"$ref,omitempty\"`"+`
+	Name2      string `+"`json:\"name,omitempty\"`"+`
+	Name       string `+"`json:\"Name,omitempty\"`"+`
+	Validate_  bool   `+"`json:\"validate,omitempty\"`"+`
+	X名前        string `+"`json:\"名前,omitempty\"`"+`
+	Über       string `+"`json:\"über,omitempty\"`"+`
+	SKU        string `+"`json:\"sku,omitempty\"`"+`
+	HTTPServer string `+"`json:\"HTTPServer,omitempty\"`"+`
+}
+
+func (instance *APIResponse) Validate() error {
+	return nil
+}
+`, "\n")
+
+	doc, err := generateWithLoadOptions(oasYaml, generator.LoadOptions{Naming: generator.NewNaming([]string{"sku"})}, generator.NamingStrategyFail, "")
+	require.NoError(t, err)
+
+	response, err := readGoFile("api_response.go")
+	require.NoError(t, err)
+	require.Equal(t, expectedResponse, response)
+
+	client, err := readGoFile("client.go")
+	require.NoError(t, err)
+	require.Contains(t, client, "\tGetSKU(ctx context.Context, sku string)")
+	require.Contains(t, client, "\tGetLink(ctx context.Context, urlParam string, httpParam string, userID string, httpServer string)")
+
+	urls, err := readGoFile("url.go")
+	require.NoError(t, err)
+	require.Contains(t, urls, "type GetSKUURLParams struct {\n\tSKU string\n}")
+
+	embedded, err := os.ReadFile(filepath.Join("gen", generator.SpecFilename))
+	require.NoError(t, err)
+	require.NotContains(t, string(embedded), "initialisms")
+	require.Empty(t, doc.Extensions)
+
+	testGenerated(t, nil)
+
+	beforeTest(t)
+
+	_, err = generateWithSpec(oasYaml)
+	require.NoError(t, err)
+
+	response, err = readGoFile("api_response.go")
+	require.NoError(t, err)
+	require.Contains(t, response, "\tSku        string `json:\"sku,omitempty\"`\n")
+}
+
+func TestPropertyOrder(t *testing.T) {
+	beforeTest(t)
+
+	schemasYaml := `
+Foo:
+  type: object
+  properties:
+    zeta:
+      type: string
+    alpha:
+      type: integer
+    id:
+      type: string
+      x-order: 1
+    mid:
+      type: boolean
+    createdAt:
+      type: string
+      x-order: 2
+`
+
+	expectedDeclared := strings.TrimPrefix(`
+// Code generated by openapi3-go-gen. DO NOT EDIT.
+
+package openapi
+
+type Foo struct {
+	ID        string `+"`json:\"id,omitempty\"`"+`
+	CreatedAt string `+"`json:\"createdAt,omitempty\"`"+`
+	Zeta      string `+"`json:\"zeta,omitempty\"`"+`
+	Alpha     int    `+"`json:\"alpha,omitempty\"`"+`
+	Mid       bool   `+"`json:\"mid,omitempty\"`"+`
+}
+
+func (instance *Foo) Validate() error {
+	return nil
+}
+`, "\n")
+
+	err := generate(schemasYaml)
+	require.NoError(t, err)
+
+	f, err := readGoFile("foo.go")
+	require.NoError(t, err)
+	require.Equal(t, expectedDeclared, f)
+
+	expectedAlphabetical := strings.TrimPrefix(`
+// Code generated by openapi3-go-gen. DO NOT EDIT.
+
+package openapi
+
+type Foo struct {
+	Alpha     int    `+"`json:\"alpha,omitempty\"`"+`
+	CreatedAt string `+"`json:\"createdAt,omitempty\"`"+`
+	ID        string `+"`json:\"id,omitempty\"`"+`
+	Mid       bool   `+"`json:\"mid,omitempty\"`"+`
+	Zeta      string `+"`json:\"zeta,omitempty\"`"+`
+}
+
+func (instance *Foo) Validate() error {
+	return nil
+}
+`, "\n")
+
+	doc, err := generator.LoadSpec("oas.yml")
+	require.NoError(t, err)
+
+	models := generator.NewSchemaResolver(doc, generator.NewFlattener(doc, generator.Naming{}).Flatten(), generator.Naming{}).Resolve()
+
+	gen := generator.NewGenerator(generator.Naming{})
+	gen.PropertyOrder = generator.PropertyOrderAlphabetical
+
+	err = gen.GenerateToFile(models, "gen")
+	require.NoError(t, err)
+
+	f, err = readGoFile("foo.go")
+	require.NoError(t, err)
+	require.Equal(t, expectedAlphabetical, f)
+}
+
+func TestDeterministicOutput(t *testing.T) {
+	oasYaml := `
+openapi: 3.0.3
+info:
+  title: Deterministic
+  version: 1.0.0
+paths:
+  /pets:
+    post:
+      operationId: createPet
+      requestBody:
+        content:
+          multipart/form-data:
+            schema:
+              type: object
+              properties:
+                name:
+                  type: string
+                photo:
+                  type: string
+                  format: binary
+      responses:
+        "201":
+          description: Created
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/Pet'
+        default:
+          description: Error
+          content:
+            application/problem+json:
+              schema:
+                $ref: '#/components/schemas/Problem'
+      callbacks:
+        petCreated:
+          '{$request.body#/callbackUrl}':
+            post:
+              requestBody:
+                content:
+                  application/json:
+                    schema:
+                      type: object
+                      properties:
+                        id:
+                          type: string
+              responses:
+                "200":
+                  description: OK
+  /pets/events:
+    get:
+      operationId: watchPets
+      responses:
+        "200":
+          description: Events
+          content:
+            text/event-stream:
+              schema:
+                type: object
+                properties:
+                  pet:
+                    $ref: '#/components/schemas/Pet'
+components:
+  schemas:
+    Pet:
+      allOf:
+        - $ref: '#/components/schemas/Base'
+        - type: object
+          properties:
+            name:
+              type: string
+            id:
+              type: integer
+            owner:
+              type: object
+              properties:
+                name:
+                  type: string
+    PetOwner:
+      type: object
+      properties:
+        email:
+          type: string
+    Base:
+      type: object
+      properties:
+        id:
+          type: string
+        tags:
+          type: object
+          properties:
+            label:
+              type: string
+    BaseTag:
+      type: object
+      properties:
+        value:
+          type: string
+    Problem:
+      type: object
+      properties:
+        title:
+          type: string
+        detail:
+          type: string
+`
+
+	generateOnce := func() (map[string]string, string) {
+		beforeTest(t)
+
+		stdout := os.Stdout
+		reader, writer, err := os.Pipe()
+		require.NoError(t, err)
+
+		os.Stdout = writer
+
+		logged := make(chan string)
+		go func() {
+			data, _ := io.ReadAll(reader)
+			logged <- string(data)
+		}()
+
+		_, err = generateWithStrategy(oasYaml, generator.NamingStrategySuffix)
+
+		os.Stdout = stdout
+		require.NoError(t, writer.Close())
+		require.NoError(t, err)
+
+		entries, err := os.ReadDir("gen")
+		require.NoError(t, err)
+
+		files := make(map[string]string, len(entries))
+		for _, entry := range entries {
+			files[entry.Name()], err = readGoFile(entry.Name())
+			require.NoError(t, err)
+		}
+
+		return files, <-logged
+	}
+
+	expectedFiles, expectedLog := generateOnce()
+	require.Contains(t, expectedFiles, "pet_owner_2.go")
+	require.Contains(t, expectedFiles, "base_tag_2.go")
+
+	for i := 0; i < 10; i++ {
+		files, log := generateOnce()
+		require.Equal(t, expectedFiles, files)
+		require.Equal(t, expectedLog, log)
+	}
+}
+
+func TestDocComments(t *testing.T) {
+	beforeTest(t)
+
+	schemasYaml := `
+Pet:
+  type: object
+  title: A pet of the store
+  description: |
+    Pets are sold in the store and can be adopted by any customer who signed the adoption agreement beforehand.
+
+    Prices are in cents.
+  deprecated: true
+  example:
+    name: Rex
+  properties:
+    name:
+      type: string
+      description: Name given by the owner
+      minLength: 1
+      example: Rex
+    price:
+      type: integer
+      deprecated: true
+    owner:
+      $ref: '#/components/schemas/Owner'
+Owner:
+  type: object
+  title: Owner
+  properties:
+    email:
+      type: string
+`
+
+	expectedPet := strings.TrimPrefix(`
+// Code generated by openapi3-go-gen. DO NOT EDIT.
+
+package openapi
+
+import (
+	"errors"
+)
+
+// A pet of the store
+//
+// Pets are sold in the store and can be adopted by any customer who signed the
+// adoption agreement beforehand.
+//
+// Prices are in cents.
+//
+// Example: {"name":"Rex"}
+//
+// Deprecated: the schema is marked as deprecated in the spec.
+type Pet struct {
+	// Name given by the owner
+	//
+	// Example: "Rex"
+	//
+	// Constraints: minLength 1
+	Name string `+"`json:\"name,omitempty\"`"+`
+	// Deprecated: the property is marked as deprecated in the spec.
+	Price int   `+"`json:\"price,omitempty\"`"+`
+	Owner Owner `+"`json:\"owner,omitempty\"`"+`
+}
+
+func (instance *Pet) Validate() error {
+	if len(instance.Name) < 1 {
+		return errors.New("Field Name size should not be less than 1")
+	}
+	return nil
+}
+`, "\n")
+
+	expectedOwner := strings.TrimPrefix(`
+// Code generated by openapi3-go-gen. DO NOT EDIT.
+
+package openapi
+
+type Owner struct {
+	Email string `+"`json:\"email,omitempty\"`"+`
+}
+
+func (instance *Owner) Validate() error {
+	return nil
+}
+`, "\n")
+
+	err := generate(schemasYaml)
+	require.NoError(t, err)
+
+	pet, err := readGoFile("pet.go")
+	require.NoError(t, err)
+	require.Equal(t, expectedPet, pet)
+
+	owner, err := readGoFile("owner.go")
+	require.NoError(t, err)
+	require.Equal(t, expectedOwner, owner)
+}
+
+func TestHelperFileClash(t *testing.T) {
+	beforeTest(t)
+
+	oasYaml := `
+openapi: 3.0.3
+info:
+  title: Clash
+  version: 1.0.0
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      responses:
+        '200':
+          description: OK
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/FakeClientListPetsCall'
+components:
+  schemas:
+    FakeClientListPetsCall:
+      type: object
+      properties:
+        limit:
+          type: integer
+    Spec:
+      type: object
+      properties:
+        name:
+          type: string
+`
+
+	_, err := generateWithSpec(oasYaml)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "FakeClientListPetsCall: the helper FakeClientListPetsCall and #/components/schemas/FakeClientListPetsCall")
+	require.Contains(t, err.Error(), "Spec: the helpers in spec.go and #/components/schemas/Spec")
+
+	_, err = generateWithStrategy(oasYaml, generator.NamingStrategySuffix)
+	require.NoError(t, err)
+
+	spec, err := readGoFile("spec_2.go")
+	require.NoError(t, err)
+	require.Contains(t, spec, "type Spec2 struct {")
+
+	params, err := readGoFile("fake_client_list_pets_call_2.go")
+	require.NoError(t, err)
+	require.Contains(t, params, "type FakeClientListPetsCall2 struct {")
+}
+
+func TestHelperNamesOfModelsOnlySpec(t *testing.T) {
+	beforeTest(t)
+
+	oasYaml := `
+openapi: 3.0.3
+info:
+  title: Models
+  version: 1.0.0
+paths: {}
+components:
+  schemas:
+    Content:
+      type: object
+      properties:
+        body:
+          type: string
+    Handler:
+      type: object
+      properties:
+        name:
+          type: string
+    Spec:
+      type: object
+      properties:
+        version:
+          type: string
+`
+
+	err := os.WriteFile("oas.yml", []byte(oasYaml), 0777)
+	require.NoError(t, err)
+
+	doc, err := generator.LoadSpec("oas.yml")
+	require.NoError(t, err)
+
+	err = generator.NewModelNamer(doc, generator.NamingStrategyFail, generator.Naming{}).Resolve()
+	require.NoError(t, err)
+
+	modelNamer := generator.NewModelNamer(doc, generator.NamingStrategyFail, generator.Naming{})
+	modelNamer.Helpers = generator.HelperOptions{EmbedSpec: true}
+
+	err = modelNamer.Resolve()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "Spec: the helpers in spec.go and #/components/schemas/Spec")
+	require.NotContains(t, err.Error(), "Handler")
+}
+
+func TestOperationNameClash(t *testing.T) {
+	beforeTest(t)
+
+	oasYaml := `
+openapi: 3.0.3
+info:
+  title: Clash
+  version: 1.0.0
+paths:
+  /thing:
+    get:
+      operationId: getThing
+      responses:
+        '204':
+          description: No content
+  /things/{id}:
+    get:
+      operationId: get_thing
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema:
+            type: string
+      responses:
+        '204':
+          description: No content
+`
+
+	_, err := generateWithStrategy(oasYaml, generator.NamingStrategySuffix)
+	require.EqualError(t, err, "operation name collisions, give the operations distinct operationIds:\n"+
+		"GetThing: #/paths/~1thing/get and #/paths/~1things~1{id}/get")
+
+	beforeTest(t)
+
+	oasYaml = `
+openapi: 3.0.3
+info:
+  title: Clash
+  version: 1.0.0
+paths:
+  /orders:
+    post:
+      operationId: createOrder
+      responses:
+        '204':
+          description: No content
+      callbacks:
+        done:
+          '{$request.header.X-Callback}':
+            post:
+              operationId: notify
+              responses:
+                '204':
+                  description: No content
+  /refunds:
+    post:
+      operationId: createRefund
+      responses:
+        '204':
+          description: No content
+      callbacks:
+        done:
+          '{$request.header.X-Callback}':
+            post:
+              operationId: notify
+              responses:
+                '204':
+                  description: No content
+`
+
+	_, err = generateWithStrategy(oasYaml, generator.NamingStrategySuffix)
+	require.EqualError(t, err, "operation name collisions, give the operations distinct operationIds:\n"+
+		"Notify: #/paths/~1orders/post/callbacks/done/{$request.header.X-Callback}/post and "+
+		"#/paths/~1refunds/post/callbacks/done/{$request.header.X-Callback}/post")
+}
+
+const helpersOasYaml = `
+openapi: 3.0.3
+info:
+  title: Helpers
+  version: 1.0.0
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      x-pagination:
+        cursorParam: cursor
+        nextCursorField: nextCursor
+        itemsField: items
+      parameters:
+        - name: cursor
+          in: query
+          schema:
+            type: string
+      responses:
+        '200':
+          description: OK
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/PetPage'
+        default:
+          description: Error
+          content:
+            application/problem+json:
+              schema:
+                $ref: '#/components/schemas/Problem'
+    post:
+      operationId: createPet
+      security:
+        - api_key: []
+      requestBody:
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/Pet'
+          application/octet-stream: {}
+      responses:
+        '201':
+          description: Created
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/Pet'
+            text/csv:
+              schema:
+                type: string
+      callbacks:
+        created:
+          '{$request.header.X-Callback}':
+            post:
+              requestBody:
+                content:
+                  application/json:
+                    schema:
+                      $ref: '#/components/schemas/Pet'
+              responses:
+                '204':
+                  description: Received
+  /pets/{id}/photo:
+    post:
+      operationId: uploadPhoto
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema:
+            type: integer
+      requestBody:
+        content:
+          multipart/form-data:
+            schema:
+              type: object
+              properties:
+                photo:
+                  type: string
+                  format: binary
+          application/x-www-form-urlencoded:
+            schema:
+              type: object
+              properties:
+                url:
+                  type: string
+      responses:
+        '204':
+          description: Uploaded
+  /events:
+    get:
+      operationId: watchEvents
+      responses:
+        '200':
+          description: OK
+          content:
+            text/event-stream:
+              schema:
+                $ref: '#/components/schemas/Pet'
+            application/x-ndjson:
+              schema:
+                $ref: '#/components/schemas/Pet'
+components:
+  schemas:
+    Pet:
+      type: object
+      properties:
+        name:
+          type: string
+    PetPage:
+      type: object
+      properties:
+        items:
+          type: array
+          items:
+            $ref: '#/components/schemas/Pet'
+        nextCursor:
+          type: string
+    Problem:
+      type: object
+      properties:
+        title:
+          type: string
+  securitySchemes:
+    api_key:
+      type: apiKey
+      in: header
+      name: X-API-Key
+`
+
+const helpersAsyncAPI = `
+asyncapi: 2.6.0
+info:
+  title: Users
+  version: 1.0.0
+channels:
+  user/deleted:
+    publish:
+      message:
+        name: userDeleted
+        payload:
+          type: object
+          properties:
+            id:
+              type: string
+`
+
+// TestHelperIdentifiersReserved renders every template and checks that a schema named after any exported identifier
+// of the helpers is rejected, so the hand-kept lists of helper and router identifiers can't fall behind.
+func TestHelperIdentifiersReserved(t *testing.T) {
+	helpers := []struct {
+		spec   string
+		router string
+	}{
+		{helpersOasYaml, generator.RouterChi},
+		{helpersOasYaml, generator.RouterEcho},
+		{helpersOasYaml, generator.RouterGin},
+		{helpersAsyncAPI, ""},
+	}
+
+	rendered := make(map[string]bool)
+
+	for _, helper := range helpers {
+		beforeTest(t)
+
+		_, err := generateWithOptions(helper.spec, generator.NamingStrategyFail, helper.router)
+		require.NoError(t, err)
+
+		for filename, identifiers := range helperFileIdentifiers(t) {
+			rendered[filename] = true
+
+			for _, identifier := range identifiers {
+				doc, err := generator.LoadSpec("oas.yml")
+				require.NoError(t, err)
+
+				// x-go-name keeps the model name from being singularized or recased
+				schema := spec3.NewObjectSchema().WithProperty("id", spec3.NewStringSchema())
+				schema.Extensions = map[string]interface{}{generator.GoNameExtension: json.RawMessage(`"` + identifier + `"`)}
+				doc.Components.Schemas[identifier] = schema.NewRef()
+
+				modelNamer := generator.NewModelNamer(doc, generator.NamingStrategyFail, generator.Naming{})
+				modelNamer.Helpers = generator.HelperOptions{
+					EmbedSpec:       true,
+					MockServer:      true,
+					AsyncInterfaces: true,
+					Router:          helper.router,
+				}
+
+				err = modelNamer.Resolve()
+				require.Errorf(t, err, "%s of %s is not reserved", identifier, filename)
+				require.Contains(t, err.Error(), identifier+":")
+			}
+		}
+	}
+
+	templates, err := filepath.Glob("../pkg/generator/templates/*.tmpl")
+	require.NoError(t, err)
+
+	// struct.tmpl renders the models, the router templates all render router.go and problem.tmpl problem_response.go
+	require.Len(t, rendered, len(templates)-3)
+	require.True(t, rendered["router.go"])
+	require.True(t, rendered["problem_response.go"])
+}
+
+// helperFileIdentifiers lists the exported package level identifiers of the generated files that hold no model.
+func helperFileIdentifiers(t *testing.T) map[string][]string {
+	doc, err := generator.LoadSpec("oas.yml")
+	require.NoError(t, err)
+
+	modelFiles := make(map[string]bool)
+	for name := range generator.NewSchemaResolver(doc, generator.NewFlattener(doc, generator.Naming{}).Flatten(), generator.Naming{}).Resolve() {
+		modelFiles[strcase.ToSnake(name)+".go"] = true
+	}
+
+	filenames, err := filepath.Glob("gen/*.go")
+	require.NoError(t, err)
+
+	identifiers := make(map[string][]string)
+
+	for _, path := range filenames {
+		filename := filepath.Base(path)
+		if modelFiles[filename] {
+			continue
+		}
+
+		file, err := parser.ParseFile(token.NewFileSet(), path, nil, 0)
+		require.NoError(t, err)
+
+		identifiers[filename] = make([]string, 0)
+
+		for _, decl := range file.Decls {
+			switch decl := decl.(type) {
+			case *ast.FuncDecl:
+				if decl.Recv == nil && decl.Name.IsExported() {
+					identifiers[filename] = append(identifiers[filename], decl.Name.Name)
+				}
+			case *ast.GenDecl:
+				for _, spec := range decl.Specs {
+					switch spec := spec.(type) {
+					case *ast.TypeSpec:
+						if spec.Name.IsExported() {
+							identifiers[filename] = append(identifiers[filename], spec.Name.Name)
+						}
+					case *ast.ValueSpec:
+						for _, name := range spec.Names {
+							if name.IsExported() {
+								identifiers[filename] = append(identifiers[filename], name.Name)
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+
+	return identifiers
+}
+
+const validationOasYaml = `
+openapi: 3.0.3
+info:
+  title: Pets
+  version: 1.0.0
+paths:
+  /pets:
+    post:
+      operationId: createPet
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/Pet'
+      responses:
+        '201':
+          description: Created
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/Pet'
+  /pets/{id}:
+    get:
+      operationId: getPet
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema:
+            type: integer
+      responses:
+        '200':
+          description: OK
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/Pet'
+              example:
+                id: 1
+                name: Rex
+components:
+  schemas:
+    Pet:
+      type: object
+      required:
+        - name
+      properties:
+        id:
+          type: integer
+        name:
+          type: string
+`
+
+func TestValidationMiddlewareRuntime(t *testing.T) {
+	beforeTest(t)
+
+	_, err := generateWithSpec(validationOasYaml)
+	require.NoError(t, err)
+
+	testGenerated(t, map[string]string{"validation_test.go": `package openapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestValidationMiddleware(t *testing.T) {
+	handler := ValidationMiddleware(ValidationOptions{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	for _, tc := range []struct {
+		method string
+		path   string
+		body   string
+		status int
+	}{
+		{http.MethodGet, "/pets/1", "", http.StatusNoContent},
+		{http.MethodGet, "/pets/abc", "", http.StatusBadRequest},
+		{http.MethodPost, "/pets", ` + "`" + `{"name":"Rex"}` + "`" + `, http.StatusNoContent},
+		{http.MethodPost, "/pets", ` + "`" + `{"id":1}` + "`" + `, http.StatusBadRequest},
+		{http.MethodGet, "/unknown", "", http.StatusNotFound},
+	} {
+		req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+		req.Header.Set("Content-Type", "application/json")
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != tc.status {
+			t.Errorf("%s %s: got status %d, want %d: %s", tc.method, tc.path, rec.Code, tc.status, rec.Body)
+		}
+	}
+}
+`})
+}
+
+const serversOasYaml = `
+openapi: 3.0.3
+info:
+  title: Users
+  version: 1.0.0
+servers:
+  - url: https://{region}.example.com/v1
+    variables:
+      region:
+        default: eu
+paths:
+  /users/{id}:
+    get:
+      operationId: getUser
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema:
+            type: string
+      responses:
+        '200':
+          description: OK
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/User'
+  /files/{id}:
+    get:
+      operationId: getFile
+      security:
+        - api_key: []
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema:
+            type: integer
+      responses:
+        '204':
+          description: No content
+components:
+  schemas:
+    User:
+      type: object
+      required:
+        - name
+      properties:
+        name:
+          type: string
+  securitySchemes:
+    api_key:
+      type: apiKey
+      in: header
+      name: X-API-Key
+`
+
+const serversValidationTestGo = `package openapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3filter"
+)
+
+func TestValidationMiddlewareWithServers(t *testing.T) {
+	handler := ValidationMiddleware(ValidationOptions{ExcludeRequestBody: true, AuthenticationFunc: func(_ context.Context, _ *openapi3filter.AuthenticationInput) error {
+		return nil
+	}})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	for _, tc := range []struct {
+		path   string
+		status int
+	}{
+		{"/v1/users/abc", http.StatusNoContent},
+		{"/users/abc", http.StatusNoContent},
+		{"/v1/files/1", http.StatusNoContent},
+		{"/files/1", http.StatusNoContent},
+		{"/v1/files/abc", http.StatusBadRequest},
+		{"/v1/unknown", http.StatusNotFound},
+		{"/v2/users/abc", http.StatusNotFound},
+	} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+		if rec.Code != tc.status {
+			t.Errorf("GET %s: got status %d, want %d: %s", tc.path, rec.Code, tc.status, rec.Body)
+		}
+	}
+}
+`
+
+const serversResponseValidationTestGo = `package openapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestResponseValidationWithServers(t *testing.T) {
+	handler := ResponseValidationMiddleware(ResponseValidationOptions{Mode: ResponseValidationFail})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+
+			switch r.URL.Path {
+			case "/v1/users/abc", "/users/abc":
+				_, _ = w.Write([]byte(` + "`" + `{"name":"Ann"}` + "`" + `))
+			default:
+				w.WriteHeader(http.StatusTeapot)
+			}
+		}),
+	)
+
+	for _, tc := range []struct {
+		path   string
+		status int
+	}{
+		{"/v1/users/abc", http.StatusOK},
+		{"/users/abc", http.StatusOK},
+		{"/v1/files/1", http.StatusInternalServerError},
+		{"/files/1", http.StatusInternalServerError},
+		{"/v1/unknown", http.StatusInternalServerError},
+	} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+		if rec.Code != tc.status {
+			t.Errorf("GET %s: got status %d, want %d: %s", tc.path, rec.Code, tc.status, rec.Body)
+		}
+	}
+}
+
+func TestValidatingTransportWithServers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: &ValidatingTransport{Options: ResponseValidationOptions{Mode: ResponseValidationFail}}}
+
+	if _, err := client.Get(server.URL + "/v1/files/1"); err == nil {
+		t.Fatal("undocumented status: expected an error")
+	}
+}
+`
+
+const serversSecurityTestGo = `package openapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type keyAuthenticator string
+
+func (a keyAuthenticator) Authenticate(_ context.Context, credentials *AuthCredentials) error {
+	if credentials.APIKey != string(a) {
+		return errors.New("invalid key")
+	}
+
+	return nil
+}
+
+func TestSecurityMiddlewareWithServers(t *testing.T) {
+	handler := SecurityMiddleware(keyAuthenticator("secret"))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	for _, tc := range []struct {
+		path   string
+		key    string
+		status int
+	}{
+		{"/v1/users/abc", "", http.StatusNoContent},
+		{"/users/abc", "", http.StatusNoContent},
+		{"/v1/files/1", "secret", http.StatusNoContent},
+		{"/files/1", "secret", http.StatusNoContent},
+		{"/v1/files/1", "", http.StatusUnauthorized},
+		{"/v1/files/1", "wrong", http.StatusUnauthorized},
+		{"/v1/unknown", "secret", http.StatusNotFound},
+	} {
+		req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		if tc.key != "" {
+			req.Header.Set("X-API-Key", tc.key)
+		}
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != tc.status {
+			t.Errorf("GET %s with key %q: got status %d, want %d", tc.path, tc.key, rec.Code, tc.status)
+		}
+	}
+}
+`
+
+func TestServersRoutingRuntime(t *testing.T) {
+	beforeTest(t)
+
+	_, err := generateWithSpec(serversOasYaml)
+	require.NoError(t, err)
+
+	testGenerated(t, map[string]string{
+		"validation_test.go":          serversValidationTestGo,
+		"response_validation_test.go": serversResponseValidationTestGo,
+		"security_test.go":            serversSecurityTestGo,
+	})
+}
+
+func TestResponseValidationRuntime(t *testing.T) {
+	beforeTest(t)
+
+	_, err := generateWithSpec(validationOasYaml)
+	require.NoError(t, err)
+
+	testGenerated(t, map[string]string{"response_validation_test.go": `package openapi
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func petHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/pets/1":
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(` + "`" + `{"id":1,"name":"Rex"}` + "`" + `))
+	case "/pets/2":
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(` + "`" + `{"id":2}` + "`" + `))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestResponseValidationMiddleware(t *testing.T) {
+	handler := ResponseValidationMiddleware(ResponseValidationOptions{Mode: ResponseValidationFail})(http.HandlerFunc(petHandler))
+
+	for _, tc := range []struct {
+		path   string
+		status int
+	}{
+		{"/pets/1", http.StatusOK},
+		{"/pets/2", http.StatusInternalServerError},
+		{"/unknown", http.StatusInternalServerError},
+	} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+		if rec.Code != tc.status {
+			t.Errorf("GET %s: got status %d, want %d: %s", tc.path, rec.Code, tc.status, rec.Body)
+		}
+	}
+}
+
+func TestResponseValidationLog(t *testing.T) {
+	var logs bytes.Buffer
+
+	options := ResponseValidationOptions{Mode: ResponseValidationLog, Logger: log.New(&logs, "", 0)}
+	handler := ResponseValidationMiddleware(options)(http.HandlerFunc(petHandler))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/unknown", nil))
+
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("got status %d", rec.Code)
+	}
+
+	if !strings.Contains(logs.String(), "response to GET /unknown") {
+		t.Errorf("got logs %q", logs.String())
+	}
+}
+
+func TestValidatingTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(petHandler))
+	defer server.Close()
+
+	client := &http.Client{Transport: &ValidatingTransport{Options: ResponseValidationOptions{Mode: ResponseValidationFail}}}
+
+	resp, err := client.Get(server.URL + "/pets/1")
+	if err != nil {
+		t.Fatalf("valid response: %v", err)
+	}
+	_ = resp.Body.Close()
+
+	if _, err := client.Get(server.URL + "/pets/2"); err == nil {
+		t.Fatal("invalid response: expected an error")
+	}
+
+	if _, err := client.Get(server.URL + "/unknown"); err == nil {
+		t.Fatal("unknown route: expected an error")
+	}
+}
+`})
+}
+
+func TestMockServerRuntime(t *testing.T) {
+	beforeTest(t)
+
+	_, err := generateWithSpec(validationOasYaml)
+	require.NoError(t, err)
+
+	testGenerated(t, map[string]string{"mock_test.go": `package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMockServer(t *testing.T) {
+	validate := ValidationMiddleware(ValidationOptions{})
+	validateResponse := ResponseValidationMiddleware(ResponseValidationOptions{Mode: ResponseValidationFail})
+
+	server := httptest.NewServer(validate(validateResponse(NewMockServer())))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/pets/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var pet Pet
+	if err := json.NewDecoder(resp.Body).Decode(&pet); err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK || pet.Name != "Rex" {
+		t.Errorf("valid request: got status %d and %+v", resp.StatusCode, pet)
+	}
+
+	for _, tc := range []struct {
+		method string
+		path   string
+		body   string
+		status int
+	}{
+		{http.MethodGet, "/pets/abc", "", http.StatusBadRequest},
+		{http.MethodPost, "/pets", ` + "`" + `{"id":1}` + "`" + `, http.StatusBadRequest},
+		{http.MethodGet, "/unknown", "", http.StatusNotFound},
+	} {
+		req, err := http.NewRequest(tc.method, server.URL+tc.path, strings.NewReader(tc.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = resp.Body.Close()
+
+		if resp.StatusCode != tc.status {
+			t.Errorf("%s %s: got status %d, want %d", tc.method, tc.path, resp.StatusCode, tc.status)
+		}
+	}
+}
+
+func TestMockServerOverride(t *testing.T) {
+	mock := NewMockServer()
+	mock.GetPetFunc = func(w http.ResponseWriter, r *http.Request, id int) {
+		w.WriteHeader(http.StatusTeapot + id)
+	}
+
+	rec := httptest.NewRecorder()
+	mock.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/pets/1", nil))
+
+	if rec.Code != http.StatusTeapot+1 {
+		t.Errorf("got status %d", rec.Code)
+	}
+}
+
+func TestMockServerRoutes(t *testing.T) {
+	mux := http.NewServeMux()
+	for _, route := range Routes(NewMockServer(), ServerOptions{BaseURL: "/api"}) {
+		if route.Name == "CreatePet" {
+			mux.Handle(route.Path, route.Handler)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/pets", strings.NewReader(` + "`" + `{"name":"Rex"}` + "`" + `)))
+
+	if rec.Code != http.StatusCreated || !strings.Contains(rec.Body.String(), ` + "`" + `"name":"string"` + "`" + `) {
+		t.Errorf("got status %d and %s", rec.Code, rec.Body)
+	}
+}
+`})
+}